@@ -3,6 +3,7 @@ module github.com/muratmirgun/socketeer
 go 1.24.1
 
 require (
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/gin-gonic/gin v1.10.1
 	github.com/gorilla/websocket v1.5.3
 	github.com/spf13/cobra v1.9.1
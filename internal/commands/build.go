@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/muratmirgun/socketeer/internal/docs"
+	"github.com/muratmirgun/socketeer/internal/spec"
+	"github.com/spf13/cobra"
+)
+
+var buildFile string
+var buildOut string
+var buildFormat string
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build static HTML and Markdown documentation",
+	Long: `Renders wsapi.yaml into a single index.html that works offline (spec, CSS and JS inlined)
+and a Markdown export with one file per socket group, ready to commit or publish to GitHub Pages.
+Protocol diagrams are drawn with Mermaid from its CDN, and shown as Mermaid source offline.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		configured(cmd, "file", &buildFile, specFile())
+		if buildFormat != "html" && buildFormat != "markdown" && buildFormat != "all" {
+			fmt.Printf("Error: unknown format %q (expected html, markdown or all)\n", buildFormat)
+			os.Exit(1)
+		}
+		s, err := spec.Load(buildFile)
+		if err != nil {
+			fmt.Printf("Error loading spec: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.MkdirAll(buildOut, 0755); err != nil {
+			fmt.Printf("Error creating output directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		if buildFormat == "html" || buildFormat == "all" {
+			page, err := docs.HTML(s)
+			if err != nil {
+				fmt.Printf("Error rendering HTML: %v\n", err)
+				os.Exit(1)
+			}
+			path := filepath.Join(buildOut, "index.html")
+			if err := os.WriteFile(path, page, 0644); err != nil {
+				fmt.Printf("Error writing %s: %v\n", path, err)
+				os.Exit(1)
+			}
+			fmt.Printf("✅ HTML written to %s\n", path)
+		}
+
+		if buildFormat == "markdown" || buildFormat == "all" {
+			files, err := docs.Markdown(s)
+			if err != nil {
+				fmt.Printf("Error rendering Markdown: %v\n", err)
+				os.Exit(1)
+			}
+			mdDir := filepath.Join(buildOut, "markdown")
+			if err := os.MkdirAll(mdDir, 0755); err != nil {
+				fmt.Printf("Error creating output directory: %v\n", err)
+				os.Exit(1)
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(mdDir, name), content, 0644); err != nil {
+					fmt.Printf("Error writing %s: %v\n", name, err)
+					os.Exit(1)
+				}
+			}
+			fmt.Printf("✅ Markdown written to %s (%d files)\n", mdDir, len(files))
+		}
+	},
+}
+
+func init() {
+	buildCmd.Flags().StringVar(&buildFile, "file", "wsdocs/wsapi.yaml", "Spec file to render")
+	buildCmd.Flags().StringVar(&buildOut, "out", "wsdocs/site", "Output directory")
+	buildCmd.Flags().StringVar(&buildFormat, "format", "all", "Output format: html, markdown or all")
+	rootCmd.AddCommand(buildCmd)
+}
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/muratmirgun/socketeer/internal/config"
+	"github.com/muratmirgun/socketeer/internal/parser"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var configFile string
+
+// cfg is the project configuration, loaded before any command runs.
+var cfg = config.Default()
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the project configuration",
+}
+
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "Print the effective configuration",
+	Long: `Prints the configuration commands run with: the built-in defaults, overridden by
+.socketeer.yaml (or --config) and then by SOCKETEER_* environment variables.
+Flags given to a command override it further.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(cfg); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+		enc.Close()
+	},
+}
+
+// loadConfig reads the configuration file and environment variables. The
+// default file is optional; one named with --config must exist.
+func loadConfig(cmd *cobra.Command, args []string) {
+	if cmd.Flags().Changed("config") {
+		if _, err := os.Stat(configFile); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	c, err := config.Load(configFile)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	cfg = c
+}
+
+// configured sets a flag's variable from the configuration unless the flag was given.
+func configured[T any](cmd *cobra.Command, flag string, v *T, value T) {
+	if !cmd.Flags().Changed(flag) {
+		*v = value
+	}
+}
+
+// specFile returns the spec file commands read by default: the one generate
+// writes, the YAML one when it writes both formats.
+func specFile() string {
+	paths, err := specOutputs(cfg.Output.Path, cfg.Output.Format)
+	if err != nil {
+		return cfg.Output.Path
+	}
+	return paths[0]
+}
+
+// sourceIndex returns an index of the configured source directories, or of
+// dir if the --src flag was given. Build tags and the cache directory come
+// from the --tags and --cache flags or the configuration.
+func sourceIndex(cmd *cobra.Command, dir string) *parser.Index {
+	dirs := cfg.Source.Dirs
+	if cmd.Flags().Changed("src") {
+		dirs = []string{dir}
+	}
+	ix := parser.NewIndex(dirs...)
+	ix.Include, ix.Exclude = cfg.Source.Include, cfg.Source.Exclude
+	ix.Tags, ix.Generated = cfg.Source.Tags, cfg.Source.Generated
+	if cmd.Flags().Changed("tags") {
+		ix.Tags, _ = cmd.Flags().GetStringSlice("tags")
+	}
+	ix.CacheDir = cfg.Source.Cache
+	if cmd.Flags().Changed("cache") {
+		ix.CacheDir, _ = cmd.Flags().GetString("cache")
+	}
+	ix.Defaults, ix.DefaultServers = cfg.Info, cfg.Servers
+	return ix
+}
+
+// addSourceFlags adds the --tags and --cache flags read by sourceIndex.
+func addSourceFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("tags", nil, "Comma-separated build tags files are matched against, as with go build -tags")
+	cmd.Flags().String("cache", "", "Directory keeping parse results between runs, so only changed files are parsed again")
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", config.File, "Project configuration file")
+	rootCmd.PersistentPreRun = loadConfig
+	configCmd.AddCommand(configPrintCmd)
+	rootCmd.AddCommand(configCmd)
+}
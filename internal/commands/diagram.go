@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/muratmirgun/socketeer/internal/diagram"
+	"github.com/muratmirgun/socketeer/internal/docs"
+	"github.com/muratmirgun/socketeer/internal/spec"
+	"github.com/spf13/cobra"
+)
+
+var diagramFile string
+var diagramSocket string
+var diagramFormat string
+var diagramTraffic string
+var diagramOut string
+
+var diagramCmd = &cobra.Command{
+	Use:   "diagram",
+	Short: "Generate sequence diagrams from wsapi.yaml or recorded traffic",
+	Long: `Renders Mermaid or PlantUML sequence diagrams for each socket in a wsapi.yaml file,
+or for a traffic export downloaded from the playground (--traffic).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		configured(cmd, "file", &diagramFile, specFile())
+		render := diagram.Mermaid
+		ext := ".mmd"
+		switch diagramFormat {
+		case "mermaid":
+		case "plantuml":
+			render = diagram.PlantUML
+			ext = ".puml"
+		default:
+			fmt.Printf("Error: unknown format %q (expected mermaid or plantuml)\n", diagramFormat)
+			os.Exit(1)
+		}
+
+		var sequences []*diagram.Sequence
+		if diagramTraffic != "" {
+			data, err := os.ReadFile(diagramTraffic)
+			if err != nil {
+				fmt.Printf("Error reading traffic export: %v\n", err)
+				os.Exit(1)
+			}
+			export, err := diagram.DecodeTraffic(data)
+			if err != nil {
+				fmt.Printf("Error parsing traffic export: %v\n", err)
+				os.Exit(1)
+			}
+			server := diagramSocket
+			if server == "" {
+				server = export.Socket
+			}
+			if server == "" {
+				server = "Server"
+			}
+			sequences = append(sequences, diagram.FromTraffic(server+" (recorded)", server, export.Frames))
+		} else {
+			s, err := spec.Load(diagramFile)
+			if err != nil {
+				fmt.Printf("Error loading spec: %v\n", err)
+				os.Exit(1)
+			}
+			for i := range s.Sockets {
+				if diagramSocket != "" && s.Sockets[i].Name != diagramSocket {
+					continue
+				}
+				sequences = append(sequences, diagram.FromSocket(&s.Sockets[i]))
+			}
+			if len(sequences) == 0 {
+				fmt.Printf("Error: no socket named %q in %s\n", diagramSocket, diagramFile)
+				os.Exit(1)
+			}
+		}
+
+		if diagramOut == "" {
+			for i, seq := range sequences {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Print(render(seq))
+			}
+			return
+		}
+		if err := os.MkdirAll(diagramOut, 0755); err != nil {
+			fmt.Printf("Error creating output directory: %v\n", err)
+			os.Exit(1)
+		}
+		names := docs.Slugs{}
+		for _, seq := range sequences {
+			// Socket names are free text; the slug keeps every file inside --out
+			// and the suffix keeps sockets with the same slug apart
+			name := names.Slug(seq.Server, "socket")
+			path := filepath.Join(diagramOut, name+ext)
+			if diagramTraffic != "" {
+				path = filepath.Join(diagramOut, name+".recorded"+ext)
+			}
+			if err := os.WriteFile(path, []byte(render(seq)), 0644); err != nil {
+				fmt.Printf("Error writing %s: %v\n", path, err)
+				os.Exit(1)
+			}
+			fmt.Printf("Diagram written to %s\n", path)
+		}
+	},
+}
+
+func init() {
+	diagramCmd.Flags().StringVar(&diagramFile, "file", "wsdocs/wsapi.yaml", "Spec file to read")
+	diagramCmd.Flags().StringVar(&diagramSocket, "socket", "", "Only render the socket with this name")
+	diagramCmd.Flags().StringVar(&diagramFormat, "format", "mermaid", "Diagram format: mermaid or plantuml")
+	diagramCmd.Flags().StringVar(&diagramTraffic, "traffic", "", "Traffic export (JSON) recorded in the playground")
+	diagramCmd.Flags().StringVar(&diagramOut, "out", "", "Directory to write one diagram per socket, named after its slug, e.g. chat-room.mmd, chat-room-2.mmd (default: stdout)")
+	rootCmd.AddCommand(diagramCmd)
+}
@@ -17,6 +17,7 @@ var fmtCmd = &cobra.Command{
 	Short: "Format wsapi.yaml file",
 	Long:  `Formats and prettifies a wsapi.yaml file with consistent indentation and structure.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		configured(cmd, "file", &fmtFile, specFile())
 		if fmtFile == "" {
 			fmtFile = "wsdocs/wsapi.yaml"
 		}
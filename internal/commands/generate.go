@@ -1,38 +1,254 @@
 package commands
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/muratmirgun/socketeer/internal/livereload"
 	"github.com/muratmirgun/socketeer/internal/parser"
+	"github.com/muratmirgun/socketeer/internal/spec"
+	"github.com/muratmirgun/socketeer/internal/watch"
 	"github.com/spf13/cobra"
 )
 
 var src string
 var out string
+var format string
+var watchMode bool
+var liveReloadAddr string
+var infer bool
+var sortSpec bool
+var check bool
+
+// status receives progress messages and diagnostics. It is stderr while the
+// spec is written to stdout.
+var status io.Writer = os.Stdout
 
 var generateCmd = &cobra.Command{
 	Use:   "generate",
 	Short: "Generate wsapi.yaml from Go source annotations",
-	Long:  `Scans Go files for WebSocket annotations and generates wsapi.yaml spec.`,
+	Long: `Scans Go files for WebSocket annotations and generates the wsapi.yaml spec.
+With --format json or both, the spec is also written as JSON next to it. --out - writes it to stdout.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if src == "" {
-			src = "./"
-		}
-		if out == "" {
-			out = "wsdocs/wsapi.yaml"
+		configured(cmd, "out", &out, cfg.Output.Path)
+		configured(cmd, "format", &format, cfg.Output.Format)
+		configured(cmd, "infer", &infer, cfg.Source.Infer)
+		configured(cmd, "sort", &sortSpec, cfg.Output.Sort)
+		if check && watchMode {
+			fmt.Println("Error: --check cannot be combined with --watch")
+			os.Exit(1)
 		}
-		fmt.Printf("Parsing Go files in %s...\n", src)
-		err := parser.ParseAndWriteSpec(src, out)
+		paths, err := specOutputs(out, format)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if out == "-" {
+			if check || watchMode {
+				fmt.Println("Error: --out - cannot be combined with --check or --watch")
+				os.Exit(1)
+			}
+			status = os.Stderr
+		}
+		ix := sourceIndex(cmd, src)
+		ix.Infer = infer
+		fmt.Fprintf(status, "Parsing Go files in %s...\n", strings.Join(ix.Dirs(), ", "))
+		if !watchMode {
+			if err := ix.Load(); err != nil {
+				fmt.Fprintf(status, "Error: %v\n", err)
+				if check || out == "-" {
+					os.Exit(1)
+				}
+				return
+			}
+			s, diags := generatedSpec(ix)
+			printDiagnostics(diags)
+			if check {
+				for _, path := range paths {
+					if err := checkSpec(&s, path); err != nil {
+						fmt.Printf("Error: %v\n", err)
+						os.Exit(1)
+					}
+					fmt.Printf("%s is up to date\n", path)
+				}
+				return
+			}
+			if out == "-" {
+				data, err := spec.Encode(&s, cmp.Or(format, spec.FormatYAML))
+				if err != nil {
+					fmt.Fprintf(status, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				os.Stdout.Write(data)
+				return
+			}
+			for _, path := range paths {
+				if _, err := spec.Write(&s, path); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return
+				}
+				fmt.Printf("Spec written to %s\n", path)
+			}
 			return
 		}
-		fmt.Printf("Spec written to %s\n", out)
+		if err := runWatch(ix, paths); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
 	},
 }
 
+// specOutputs returns the files generate writes for --out and --format. A
+// .yaml, .yml or .json extension is changed to match the format, so the
+// default wsdocs/wsapi.yaml becomes wsdocs/wsapi.json with --format json, and
+// both files are written with --format both. Without a format, the extension
+// decides it (see spec.FileFormat).
+func specOutputs(out, format string) ([]string, error) {
+	var formats []string
+	switch format {
+	case "":
+		return []string{out}, nil
+	case spec.FormatYAML, spec.FormatJSON:
+		formats = []string{format}
+	case "both":
+		if out == "-" {
+			return nil, errors.New("--out - writes a single format, use --format yaml or json")
+		}
+		formats = []string{spec.FormatYAML, spec.FormatJSON}
+	default:
+		return nil, fmt.Errorf("unknown format %q, want yaml, json or both", format)
+	}
+	var paths []string
+	for _, f := range formats {
+		path := out
+		ext := strings.ToLower(filepath.Ext(out))
+		if out != "-" && (ext == ".yaml" || ext == ".yml" || ext == ".json") && spec.FileFormat(out) != f {
+			path = strings.TrimSuffix(out, filepath.Ext(out)) + "." + f
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// writeSpecs writes the spec to every path and reports whether any file changed.
+func writeSpecs(s *spec.Spec, paths []string) (bool, error) {
+	changed := false
+	for _, path := range paths {
+		written, err := spec.Write(s, path)
+		if err != nil {
+			return changed, err
+		}
+		changed = changed || written
+	}
+	return changed, nil
+}
+
+// runWatch regenerates the spec whenever Go files change and notifies open docs pages.
+func runWatch(ix *parser.Index, paths []string) error {
+	if err := ix.Load(); err != nil {
+		return err
+	}
+	s, diags := generatedSpec(ix)
+	printDiagnostics(diags)
+	if _, err := writeSpecs(&s, paths); err != nil {
+		return err
+	}
+	fmt.Printf("Spec written to %s\n", strings.Join(paths, ", "))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	broker := livereload.NewBroker()
+	if liveReloadAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle(livereload.Path, broker)
+		srv := &http.Server{Addr: liveReloadAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fmt.Printf("Live reload disabled: %v\n", err)
+			}
+		}()
+		defer srv.Close()
+		fmt.Printf("Live reload events on http://%s%s\n", liveReloadAddr, livereload.Path)
+	}
+
+	w := &watch.Watcher{
+		Index: ix,
+		OnChange: func(changed []string) {
+			s, diags := generatedSpec(ix)
+			printDiagnostics(diags)
+			written, err := writeSpecs(&s, paths)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			if !written {
+				fmt.Printf("%d file(s) changed, spec unchanged\n", len(changed))
+				return
+			}
+			fmt.Printf("%d file(s) changed, spec written to %s\n", len(changed), strings.Join(paths, ", "))
+			broker.Notify(time.Now().Format(time.RFC3339))
+		},
+		OnError: func(err error) {
+			fmt.Printf("Error: %v\n", err)
+		},
+	}
+	fmt.Printf("Watching %s for changes (Ctrl+C to stop)...\n", strings.Join(ix.Dirs(), ", "))
+	return w.Run(ctx)
+}
+
+// generatedSpec returns the index's spec, sorted alphabetically with --sort,
+// and the problems found in the sources.
+func generatedSpec(ix *parser.Index) (spec.Spec, []parser.Diagnostic) {
+	s, diags := ix.Build()
+	if sortSpec {
+		s.Sort()
+	}
+	return s, diags
+}
+
+// checkSpec reports an error if the spec file at path differs from s.
+func checkSpec(s *spec.Spec, path string) error {
+	data, err := spec.Encode(s, spec.FileFormat(path))
+	if err != nil {
+		return err
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(existing, data) {
+		return fmt.Errorf("%s is out of date, run socketeer generate", path)
+	}
+	return nil
+}
+
+// printDiagnostics prints parser warnings, one per line.
+func printDiagnostics(diags []parser.Diagnostic) {
+	for _, d := range diags {
+		fmt.Fprintln(status, d)
+	}
+}
+
 func init() {
 	generateCmd.Flags().StringVar(&src, "src", "./", "Source directory to scan for Go files")
-	generateCmd.Flags().StringVar(&out, "out", "wsdocs/wsapi.yaml", "Output spec file (YAML)")
+	generateCmd.Flags().StringVar(&out, "out", "wsdocs/wsapi.yaml", "Output spec file, or - for stdout")
+	generateCmd.Flags().StringVar(&format, "format", "", "Output format: yaml, json or both (default: by the --out extension, YAML for stdout)")
+	generateCmd.Flags().BoolVar(&infer, "infer", false, "Infer undocumented message types from handler code")
+	generateCmd.Flags().BoolVar(&sortSpec, "sort", false, "Sort sockets, messages and payload keys alphabetically instead of keeping source order")
+	generateCmd.Flags().BoolVar(&check, "check", false, "Exit with an error instead of writing if the spec file is out of date")
+	generateCmd.Flags().BoolVar(&watchMode, "watch", false, "Watch Go files and regenerate the spec on change")
+	generateCmd.Flags().StringVar(&liveReloadAddr, "livereload", "localhost:35729", "Address for live reload events in watch mode (empty to disable)")
+	addSourceFlags(generateCmd)
 	rootCmd.AddCommand(generateCmd)
 }
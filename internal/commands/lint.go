@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/muratmirgun/socketeer/internal/lint"
+	"github.com/muratmirgun/socketeer/internal/parser"
+	"github.com/muratmirgun/socketeer/internal/spec"
+	"github.com/spf13/cobra"
+)
+
+var lintSrc string
+var lintFile string
+var lintListRules bool
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check the API against style rules",
+	Long: `Checks the documented API against the style rules configured under lint.rules
+in .socketeer.yaml (see --config). Sources are parsed as by generate unless --file names a spec.
+Findings are suppressed with //socketeer:ignore <rule-id> next to the socket's annotations.
+Exits with status 1 if any finding has severity error.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if lintListRules {
+			for _, r := range lint.Rules() {
+				fmt.Printf("%-20s %s\n", r.ID, r.Description)
+			}
+			return
+		}
+		var s *spec.Spec
+		var err error
+		sources := map[string]parser.SocketSource{}
+		if lintFile != "" {
+			if s, err = spec.Load(lintFile); err != nil {
+				fmt.Printf("Error loading spec: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			ix := sourceIndex(cmd, lintSrc)
+			if err := ix.Load(); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			generated := ix.Spec()
+			s, sources = &generated, ix.Sources()
+		}
+
+		findings, err := lint.Run(s, cfg.Lint)
+		if err != nil {
+			fmt.Printf("Error: %s: %v\n", configFile, err)
+			os.Exit(1)
+		}
+		failed := false
+		reported := 0
+		for _, f := range findings {
+			src := sources[f.Socket]
+			if slices.Contains(src.Ignore, f.Rule) {
+				continue
+			}
+			if src.Pos.IsValid() {
+				fmt.Printf("%s: %s\n", src.Pos, f)
+			} else {
+				fmt.Println(f)
+			}
+			failed = failed || f.Severity == lint.SeverityError
+			reported++
+		}
+		if reported == 0 {
+			fmt.Println("✅ No lint findings")
+			return
+		}
+		fmt.Printf("%d lint finding(s)\n", reported)
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	lintCmd.Flags().StringVar(&lintSrc, "src", "./", "Source directory to scan for Go files")
+	lintCmd.Flags().StringVar(&lintFile, "file", "", "Lint a spec file instead of Go sources (//socketeer:ignore is not available)")
+	lintCmd.Flags().BoolVar(&lintListRules, "rules", false, "List the available rules")
+	addSourceFlags(lintCmd)
+	rootCmd.AddCommand(lintCmd)
+}
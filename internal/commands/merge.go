@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/muratmirgun/socketeer/internal/spec"
+	"github.com/spf13/cobra"
+)
+
+var mergeOut string
+var mergeFormat string
+var mergeNamespace bool
+var mergeInfoPolicy string
+var mergeTitle string
+var mergeVersion string
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [service=]spec.yaml...",
+	Short: "Merge the specs of several services into one",
+	Long: `Merges wsapi.yaml (or JSON) files of several services into one spec for a combined portal.
+Each socket records its service, named by the service= prefix of its file or else by the
+spec's info title. Duplicate socket names, sockets with the same URL on a common server and
+servers declared differently are conflicts; they are all reported and nothing is written.`,
+	Example: `  socketeer merge chat=services/chat/wsapi.yaml billing=services/billing/wsapi.yaml --out wsdocs/wsapi.yaml
+  socketeer merge --namespace --title "Platform API" a.yaml b.yaml`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := spec.MergeOptions{
+			Namespace:  mergeNamespace,
+			InfoPolicy: mergeInfoPolicy,
+			Info:       spec.Info{Title: mergeTitle, Version: mergeVersion},
+		}
+		var specs []*spec.Spec
+		for _, arg := range args {
+			service, path := serviceArg(arg)
+			s, err := spec.Load(path)
+			if err != nil {
+				fmt.Printf("Error loading spec: %v\n", err)
+				os.Exit(1)
+			}
+			specs = append(specs, s)
+			opts.Services = append(opts.Services, service)
+		}
+
+		merged, err := spec.MergeWith(opts, specs...)
+		var conflicts *spec.ConflictError
+		if errors.As(err, &conflicts) {
+			for _, c := range conflicts.Conflicts {
+				fmt.Printf("Error: %s\n", c)
+			}
+			fmt.Printf("%d conflict(s), nothing written\n", len(conflicts.Conflicts))
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if mergeOut == "-" {
+			data, err := spec.Encode(merged, cmp.Or(mergeFormat, spec.FormatYAML))
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			os.Stdout.Write(data)
+			return
+		}
+		if mergeFormat != "" && mergeFormat != spec.FileFormat(mergeOut) {
+			fmt.Printf("Error: --format %s does not match %s\n", mergeFormat, mergeOut)
+			os.Exit(1)
+		}
+		if err := spec.Save(merged, mergeOut); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Merged %d spec(s) with %d socket(s) into %s\n", len(specs), len(merged.Sockets), mergeOut)
+	},
+}
+
+// serviceArg splits a service=path argument. Without a service name, or when
+// the = belongs to the path, the name is empty.
+func serviceArg(arg string) (service, path string) {
+	name, rest, ok := strings.Cut(arg, "=")
+	if !ok || name == "" || strings.ContainsAny(name, `/\`) {
+		return "", arg
+	}
+	return name, rest
+}
+
+func init() {
+	mergeCmd.Flags().StringVar(&mergeOut, "out", "-", "Output spec file, or - for stdout")
+	mergeCmd.Flags().StringVar(&mergeFormat, "format", "", "Output format: yaml or json (default: by the --out extension, YAML for stdout)")
+	mergeCmd.Flags().BoolVar(&mergeNamespace, "namespace", false, "Prefix socket names with their service, and rename servers that differ between services")
+	mergeCmd.Flags().StringVar(&mergeInfoPolicy, "info", spec.InfoFirst, "Info merge policy: first, last or strict")
+	mergeCmd.Flags().StringVar(&mergeTitle, "title", "", "Title of the merged spec (default: from the info policy)")
+	mergeCmd.Flags().StringVar(&mergeVersion, "api-version", "", "Version of the merged spec (default: from the info policy)")
+	rootCmd.AddCommand(mergeCmd)
+}
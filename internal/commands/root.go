@@ -11,7 +11,7 @@ var rootCmd = &cobra.Command{
 	Use:     "socketeer",
 	Short:   "socketeer - WebSocket API doc & playground generator",
 	Long:    `socketeer is a modern, Swagger-like documentation and playground generator for WebSocket APIs in Go.`,
-	Example: `  socketeer init\n  socketeer generate --src ./ --out ./wsdocs/wsapi.yaml\n  socketeer serve\n  socketeer validate\n  socketeer fmt\n  socketeer version`,
+	Example: `  socketeer init\n  socketeer generate --src ./ --out ./wsdocs/wsapi.yaml\n  socketeer serve\n  socketeer validate\n  socketeer lint\n  socketeer merge chat=chat.yaml billing=billing.yaml\n  socketeer fmt\n  socketeer build\n  socketeer diagram\n  socketeer version`,
 }
 
 // Execute runs the root command.
@@ -1,34 +1,228 @@
 package commands
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
+	"github.com/muratmirgun/socketeer/internal/docserver"
+	"github.com/muratmirgun/socketeer/internal/parser"
+	"github.com/muratmirgun/socketeer/internal/spec"
+	"github.com/muratmirgun/socketeer/internal/watch"
 	"github.com/spf13/cobra"
 )
 
 var dir string
+var serveHost string
+var servePort string
+var serveSpec string
+var serveSrc string
+var serveTLSCert string
+var serveTLSKey string
+var serveProxy bool
+var serveUpstream string
+var serveInfer bool
 
 var serveCmd = &cobra.Command{
 	Use:   "serve",
-	Short: "Serve docs and playground from a directory",
-	Long:  `Starts a local HTTP server to serve index.html, wsapi.yaml, logo.png, etc. from the specified directory.`,
+	Short: "Serve docs and playground",
+	Long: `Starts an HTTP server for the documentation UI and playground.
+
+The spec is read from --spec and reloaded when the file changes, or generated
+from Go sources with --src and regenerated on every change. Open pages reload
+automatically. Files in --dir override the built-in UI.
+
+The playground connects through a WebSocket proxy at /proxy/<socket name>,
+which sends documented header parameters as real headers, forwards
+subprotocols and records frames for export. It only accepts connections from
+the docs page itself, and is enabled by default only when listening on a
+loopback host; pass --proxy to offer it on other hosts.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if dir == "" {
-			dir = "wsdocs"
+		if err := runServe(cmd); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
 		}
-		port := "8080"
-		if p := os.Getenv("SOCKETEER_PORT"); p != "" {
-			port = p
-		}
-		fmt.Printf("Serving %s at http://localhost:%s ...\n", dir, port)
-		http.Handle("/", http.FileServer(http.Dir(dir)))
-		http.ListenAndServe(":"+port, nil)
 	},
 }
 
+func runServe(cmd *cobra.Command) error {
+	configured(cmd, "host", &serveHost, cfg.Serve.Host)
+	configured(cmd, "port", &servePort, cfg.Serve.Port)
+	configured(cmd, "dir", &dir, cfg.Serve.Dir)
+	configured(cmd, "infer", &serveInfer, cfg.Source.Infer)
+	if (serveTLSCert == "") != (serveTLSKey == "") {
+		return errors.New("--tls-cert and --tls-key must be used together")
+	}
+	if serveSpec == "" {
+		// the spec in a --dir given on the command line, else the configured output
+		serveSpec = specFile()
+		if cmd.Flags().Changed("dir") {
+			serveSpec = filepath.Join(dir, "wsapi.yaml")
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	loopback := isLoopback(serveHost)
+	if !cmd.Flags().Changed("proxy") {
+		// The proxy dials any documented server; other machines must not get it unasked
+		serveProxy = loopback
+		if !loopback {
+			fmt.Printf("Playground proxy disabled on %s; pass --proxy to enable it\n", cmp.Or(serveHost, "all interfaces"))
+		}
+	}
+
+	ds := docserver.New(dir)
+	if serveProxy {
+		ds.Proxy.Upstream = serveUpstream
+		if loopback {
+			ds.Proxy.Hosts = []string{"localhost", "127.0.0.1", "::1", serveHost}
+		}
+	} else {
+		ds.Proxy = nil
+	}
+	if serveSrc != "" {
+		if err := serveFromSource(ctx, ds, sourceIndex(cmd, serveSrc)); err != nil {
+			return err
+		}
+	} else {
+		if err := serveFromFile(ctx, ds); err != nil {
+			return err
+		}
+	}
+
+	srv := &http.Server{
+		Addr:              net.JoinHostPort(serveHost, servePort),
+		Handler:           ds.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	srv.RegisterOnShutdown(ds.Broker.Close)
+
+	errCh := make(chan error, 1)
+	go func() {
+		if serveTLSCert != "" {
+			errCh <- srv.ListenAndServeTLS(serveTLSCert, serveTLSKey)
+		} else {
+			errCh <- srv.ListenAndServe()
+		}
+	}()
+
+	scheme := "http"
+	if serveTLSCert != "" {
+		scheme = "https"
+	}
+	if serveHost == "" {
+		// Listening on all interfaces; localhost is one of them
+		fmt.Printf("Serving docs on all interfaces at %s://%s ...\n", scheme, net.JoinHostPort("localhost", servePort))
+	} else {
+		fmt.Printf("Serving docs at %s://%s ...\n", scheme, net.JoinHostPort(serveHost, servePort))
+	}
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	fmt.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// isLoopback reports whether host only accepts connections from this machine.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// serveFromFile serves the spec file and reloads it whenever it changes on disk.
+func serveFromFile(ctx context.Context, ds *docserver.Server) error {
+	load := func() {
+		data, err := os.ReadFile(serveSpec)
+		if err != nil {
+			fmt.Printf("Error reading spec: %v\n", err)
+			return
+		}
+		if ds.SetSpec(data) {
+			fmt.Printf("Loaded %s\n", serveSpec)
+		}
+	}
+	load()
+	go func() {
+		if err := watch.File(ctx, serveSpec, load); err != nil {
+			fmt.Printf("Not watching %s: %v\n", serveSpec, err)
+		}
+	}()
+	return nil
+}
+
+// serveFromSource generates the spec in memory from Go sources and regenerates it on change.
+func serveFromSource(ctx context.Context, ds *docserver.Server, ix *parser.Index) error {
+	ix.Infer = serveInfer
+	if err := ix.Load(); err != nil {
+		return err
+	}
+	update := func() error {
+		s, diags := ix.Build()
+		printDiagnostics(diags)
+		data, err := spec.Encode(&s, spec.FormatYAML)
+		if err != nil {
+			return err
+		}
+		if ds.SetSpec(data) {
+			fmt.Printf("Spec regenerated from %s\n", serveSrc)
+		}
+		return nil
+	}
+	if err := update(); err != nil {
+		return err
+	}
+	w := &watch.Watcher{
+		Index: ix,
+		OnChange: func([]string) {
+			if err := update(); err != nil {
+				fmt.Printf("Error: %v\n", err)
+			}
+		},
+		OnError: func(err error) {
+			fmt.Printf("Error: %v\n", err)
+		},
+	}
+	go func() {
+		if err := w.Run(ctx); err != nil {
+			fmt.Printf("Not watching %s: %v\n", serveSrc, err)
+		}
+	}()
+	return nil
+}
+
 func init() {
-	serveCmd.Flags().StringVar(&dir, "dir", "wsdocs", "Directory to serve static files from")
+	serveCmd.Flags().StringVar(&dir, "dir", "wsdocs", "Directory with static files overriding the built-in UI")
+	serveCmd.Flags().StringVar(&serveHost, "host", "localhost", "Host to listen on (empty string listens on all interfaces)")
+	serveCmd.Flags().StringVar(&servePort, "port", "8080", "Port to listen on")
+	serveCmd.Flags().StringVar(&serveSpec, "spec", "", "Spec file to serve (default: <dir>/wsapi.yaml with --dir, else output.path of the configuration)")
+	serveCmd.Flags().StringVar(&serveSrc, "src", "", "Generate the spec from Go sources in this directory instead of reading --spec")
+	serveCmd.Flags().BoolVar(&serveInfer, "infer", false, "With --src, infer undocumented message types from handler code")
+	serveCmd.Flags().StringVar(&serveTLSCert, "tls-cert", "", "TLS certificate file")
+	serveCmd.Flags().StringVar(&serveTLSKey, "tls-key", "", "TLS private key file")
+	serveCmd.Flags().BoolVar(&serveProxy, "proxy", false, "Offer a WebSocket proxy for playground connections (default: only on loopback hosts)")
+	serveCmd.Flags().StringVar(&serveUpstream, "upstream", "", "Base URL for proxying sockets documented with relative URLs (e.g. http://localhost:8080)")
+	addSourceFlags(serveCmd)
 	rootCmd.AddCommand(serveCmd)
 }
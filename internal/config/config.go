@@ -0,0 +1,142 @@
+// Package config reads the project configuration shared by all commands.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/muratmirgun/socketeer/internal/spec"
+	"gopkg.in/yaml.v3"
+)
+
+// File is the project configuration file, read from the working directory.
+const File = ".socketeer.yaml"
+
+// Config is the project configuration. Command-line flags take precedence
+// over environment variables, which take precedence over the file.
+type Config struct {
+	Source  Source        `yaml:"source"`
+	Output  Output        `yaml:"output"`
+	Serve   Serve         `yaml:"serve"`
+	Info    spec.Info     `yaml:"info,omitempty"`    // used where the @title, @version... annotations are missing
+	Servers []spec.Server `yaml:"servers,omitempty"` // used when there are no @server annotations
+	Lint    Lint          `yaml:"lint,omitempty"`
+}
+
+// Source selects the Go files to scan.
+type Source struct {
+	Dirs    []string `yaml:"dirs"`
+	Include []string `yaml:"include,omitempty"` // globs relative to a source dir; empty includes every file
+	Exclude []string `yaml:"exclude,omitempty"`
+	Tags    []string `yaml:"tags,omitempty"` // build tags, as with go build -tags
+	// Generated includes files marked "Code generated ... DO NOT EDIT."
+	Generated bool `yaml:"generated,omitempty"`
+	Infer     bool `yaml:"infer,omitempty"`
+	// Cache is a directory keeping parse results between runs; empty disables it.
+	Cache string `yaml:"cache,omitempty"`
+}
+
+// Output is where the spec is written and read back by the other commands.
+type Output struct {
+	Path   string `yaml:"path"`
+	Format string `yaml:"format,omitempty"` // yaml, json or both; empty picks the format by the path's extension
+	Sort   bool   `yaml:"sort,omitempty"`
+}
+
+// Serve configures socketeer serve.
+type Serve struct {
+	Host string `yaml:"host,omitempty"`
+	Port string `yaml:"port"`
+	Dir  string `yaml:"dir"` // static files overriding the built-in UI
+}
+
+// Lint configures the rules of socketeer lint, keyed by rule ID.
+type Lint struct {
+	Rules map[string]Rule `yaml:"rules,omitempty"`
+}
+
+// Rule configures one lint rule. Options are only read by the rules named in their comment.
+type Rule struct {
+	Enabled  *bool  `yaml:"enabled,omitempty"`  // rules are enabled unless set to false
+	Severity string `yaml:"severity,omitempty"` // warning or error
+
+	Style   string   `yaml:"style,omitempty"`   // message-naming: camelCase, PascalCase, snake_case or kebab-case
+	Allowed []string `yaml:"allowed,omitempty"` // allowed-tags
+	Max     int      `yaml:"max,omitempty"`     // max-payload-depth
+}
+
+// Default returns the built-in configuration.
+func Default() *Config {
+	return &Config{
+		Source: Source{Dirs: []string{"./"}},
+		Output: Output{Path: "wsdocs/wsapi.yaml"},
+		Serve:  Serve{Host: "localhost", Port: "8080", Dir: "wsdocs"},
+	}
+}
+
+// Load returns the built-in configuration overridden by the file at path, if
+// it exists, and then by environment variables (see Env). Unknown keys in the
+// file are an error so that typos do not go unnoticed.
+func Load(path string) (*Config, error) {
+	c := Default()
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for _, v := range Env {
+		if value, ok := os.LookupEnv(v.Name); ok {
+			if err := v.set(c, value); err != nil {
+				return nil, fmt.Errorf("%s: %w", v.Name, err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// EnvVar is an environment variable overriding a configuration key.
+type EnvVar struct {
+	Name string
+	Key  string
+	set  func(c *Config, value string) error
+}
+
+// Env lists the environment variables read by Load. Lists are separated by
+// the OS path list separator, except for build tags which are separated by commas.
+var Env = []EnvVar{
+	{"SOCKETEER_SRC", "source.dirs", func(c *Config, v string) error { c.Source.Dirs = filepath.SplitList(v); return nil }},
+	{"SOCKETEER_INCLUDE", "source.include", func(c *Config, v string) error { c.Source.Include = filepath.SplitList(v); return nil }},
+	{"SOCKETEER_EXCLUDE", "source.exclude", func(c *Config, v string) error { c.Source.Exclude = filepath.SplitList(v); return nil }},
+	{"SOCKETEER_TAGS", "source.tags", func(c *Config, v string) error { c.Source.Tags = strings.Split(v, ","); return nil }},
+	{"SOCKETEER_GENERATED", "source.generated", func(c *Config, v string) error { return setBool(&c.Source.Generated, v) }},
+	{"SOCKETEER_INFER", "source.infer", func(c *Config, v string) error { return setBool(&c.Source.Infer, v) }},
+	{"SOCKETEER_CACHE", "source.cache", func(c *Config, v string) error { c.Source.Cache = v; return nil }},
+	{"SOCKETEER_OUT", "output.path", func(c *Config, v string) error { c.Output.Path = v; return nil }},
+	{"SOCKETEER_FORMAT", "output.format", func(c *Config, v string) error { c.Output.Format = v; return nil }},
+	{"SOCKETEER_SORT", "output.sort", func(c *Config, v string) error { return setBool(&c.Output.Sort, v) }},
+	{"SOCKETEER_HOST", "serve.host", func(c *Config, v string) error { c.Serve.Host = v; return nil }},
+	{"SOCKETEER_PORT", "serve.port", func(c *Config, v string) error { c.Serve.Port = v; return nil }},
+	{"SOCKETEER_DIR", "serve.dir", func(c *Config, v string) error { c.Serve.Dir = v; return nil }},
+}
+
+func setBool(b *bool, value string) error {
+	v, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("%q is not a boolean", value)
+	}
+	*b = v
+	return nil
+}
@@ -0,0 +1,198 @@
+package diagram
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/muratmirgun/socketeer/internal/spec"
+)
+
+// Step kinds
+const (
+	KindMessage = "message"
+	KindReply   = "reply"
+	KindError   = "error"
+)
+
+// Step is a single arrow in a sequence diagram.
+type Step struct {
+	FromClient bool
+	Label      string
+	Kind       string
+}
+
+// Sequence is a renderer-independent sequence diagram between a client and one socket.
+type Sequence struct {
+	Title  string
+	Server string
+	Steps  []Step
+}
+
+// TrafficRecord is one frame of a traffic export recorded by the playground.
+type TrafficRecord struct {
+	Timestamp string `json:"timestamp"`
+	Direction string `json:"direction"` // out: client to server, in: server to client
+	Payload   string `json:"payload"`
+}
+
+// TrafficExport is the file written by the playground's export button.
+// Older exports are a bare array of records.
+type TrafficExport struct {
+	Socket string          `json:"socket,omitempty"`
+	URL    string          `json:"url,omitempty"`
+	Frames []TrafficRecord `json:"frames"`
+}
+
+// DecodeTraffic decodes a traffic export in either the current or the bare array format.
+func DecodeTraffic(data []byte) (*TrafficExport, error) {
+	var export TrafficExport
+	if err := json.Unmarshal(data, &export.Frames); err == nil {
+		return &export, nil
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// FromSocket builds a sequence from the socket's grouped messages.
+// A receive in the same group as a send is drawn as its reply.
+func FromSocket(socket *spec.Socket) *Sequence {
+	seq := &Sequence{Title: socket.Name, Server: socket.Name}
+	for _, gm := range socket.GroupedMessages {
+		if gm.Send != nil {
+			seq.Steps = append(seq.Steps, Step{FromClient: true, Label: gm.Type, Kind: KindMessage})
+			seq.Steps = append(seq.Steps, errorSteps(gm.Send)...)
+		}
+		if gm.Receive != nil {
+			kind := KindMessage
+			if gm.Send != nil {
+				kind = KindReply
+			}
+			seq.Steps = append(seq.Steps, Step{Label: gm.Type, Kind: kind})
+			seq.Steps = append(seq.Steps, errorSteps(gm.Receive)...)
+		}
+	}
+	return seq
+}
+
+func errorSteps(msg *spec.Message) []Step {
+	var steps []Step
+	for _, e := range msg.Errors {
+		label := e.Code
+		if e.Description != "" {
+			label += " " + e.Description
+		}
+		steps = append(steps, Step{Label: label, Kind: KindError})
+	}
+	return steps
+}
+
+// FromTraffic builds a sequence from recorded frames. Frames are labelled with
+// their JSON "type" field when present and with the raw payload otherwise.
+func FromTraffic(title, server string, records []TrafficRecord) *Sequence {
+	seq := &Sequence{Title: title, Server: server}
+	for _, r := range records {
+		seq.Steps = append(seq.Steps, Step{
+			FromClient: r.Direction == "out",
+			Label:      frameLabel(r.Payload),
+			Kind:       KindMessage,
+		})
+	}
+	return seq
+}
+
+func frameLabel(payload string) string {
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &m); err == nil {
+		if t, ok := m["type"].(string); ok && t != "" {
+			return t
+		}
+	}
+	if len(payload) > 40 {
+		return payload[:37] + "..."
+	}
+	return payload
+}
+
+// Mermaid renders the sequence as a Mermaid sequenceDiagram.
+func Mermaid(seq *Sequence) string {
+	var b strings.Builder
+	b.WriteString("sequenceDiagram\n")
+	if seq.Title != "" {
+		fmt.Fprintf(&b, "    title %s\n", sanitize(seq.Title))
+	}
+	b.WriteString("    participant C as Client\n")
+	fmt.Fprintf(&b, "    participant S as %s\n", sanitize(seq.Server))
+	for _, st := range seq.Steps {
+		from, to := "S", "C"
+		if st.FromClient {
+			from, to = "C", "S"
+		}
+		arrow := "->>"
+		switch st.Kind {
+		case KindReply:
+			arrow = "-->>"
+		case KindError:
+			arrow = "--x"
+		}
+		fmt.Fprintf(&b, "    %s%s%s: %s\n", from, arrow, to, sanitize(st.Label))
+	}
+	return b.String()
+}
+
+// PlantUML renders the sequence as a PlantUML sequence diagram.
+func PlantUML(seq *Sequence) string {
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	if seq.Title != "" {
+		fmt.Fprintf(&b, "title %s\n", sanitize(seq.Title))
+	}
+	b.WriteString("participant Client\n")
+	fmt.Fprintf(&b, "participant \"%s\" as Server\n", sanitize(seq.Server))
+	for _, st := range seq.Steps {
+		from, to := "Server", "Client"
+		if st.FromClient {
+			from, to = "Client", "Server"
+		}
+		arrow := "->"
+		switch st.Kind {
+		case KindReply:
+			arrow = "-->"
+		case KindError:
+			arrow = "-[#red]>x"
+		}
+		fmt.Fprintf(&b, "%s %s %s: %s\n", from, arrow, to, sanitize(st.Label))
+	}
+	b.WriteString("@enduml\n")
+	return b.String()
+}
+
+// sanitize strips characters that terminate a statement in either diagram language.
+func sanitize(s string) string {
+	return strings.NewReplacer("\n", " ", "\r", " ", ";", ",", "#", "", "\"", "'").Replace(s)
+}
+
+// StateMermaid renders a protocol state machine as a Mermaid stateDiagram.
+func StateMermaid(p *spec.Protocol) string {
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	if p.Initial != "" {
+		fmt.Fprintf(&b, "    [*] --> %s\n", p.Initial)
+	}
+	for _, st := range p.States {
+		if st.Description != "" {
+			fmt.Fprintf(&b, "    %s : %s\n", st.Name, sanitize(st.Description))
+		}
+	}
+	for _, t := range p.Transitions {
+		fmt.Fprintf(&b, "    %s --> %s : %s\n", t.From, t.To, sanitize(t.Message))
+	}
+	for _, st := range p.States {
+		if st.Final {
+			fmt.Fprintf(&b, "    %s --> [*]\n", st.Name)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,250 @@
+package docs
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+
+	"github.com/muratmirgun/socketeer/internal/diagram"
+	"github.com/muratmirgun/socketeer/internal/spec"
+	"github.com/muratmirgun/socketeer/internal/templates"
+)
+
+// specMarker is replaced with the inlined spec in static builds of index.html.
+const specMarker = "<!-- socketeer:spec -->"
+
+const defaultTitle = "<title>Socketeer - WebSocket API Documentation</title>"
+
+// jsYAMLScript is the CDN script tag of index.html that static builds replace
+// with the vendored copy, so the page works offline.
+const jsYAMLScript = `<script src="https://cdn.jsdelivr.net/npm/js-yaml@4.1.0/dist/js-yaml.min.js"></script>`
+
+var inlineSpec = htmltemplate.Must(htmltemplate.New("spec").Parse(`<script>window.socketeerSpec = {{ . }};</script>`))
+
+// HTML renders the documentation UI as a single page with the spec and
+// js-yaml inlined, so it can be opened from disk or published without a
+// server. Only Mermaid is loaded from its CDN; offline, protocol diagrams
+// are shown as Mermaid source.
+func HTML(s *spec.Spec) ([]byte, error) {
+	page, err := templates.FS.ReadFile("index.html")
+	if err != nil {
+		return nil, err
+	}
+	jsYAML, err := templates.FS.ReadFile("vendor/js-yaml.min.js")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(page, []byte(jsYAMLScript)) {
+		return nil, fmt.Errorf("index.html does not load js-yaml from %s", jsYAMLScript)
+	}
+	page = bytes.Replace(page, []byte(jsYAMLScript), []byte("<script>"+string(jsYAML)+"</script>"), 1)
+	var script bytes.Buffer
+	if err := inlineSpec.Execute(&script, s); err != nil {
+		return nil, err
+	}
+	out := strings.Replace(string(page), specMarker, script.String(), 1)
+	if s.Info.Title != "" {
+		out = strings.Replace(out, defaultTitle, "<title>"+html.EscapeString(s.Info.Title)+"</title>", 1)
+	}
+	return []byte(out), nil
+}
+
+// Group is a set of sockets sharing the same Socket.Group, rendered as one Markdown file.
+type Group struct {
+	Name    string
+	File    string
+	Sockets []spec.Socket
+}
+
+// Groups splits the spec's sockets by group in order of first appearance.
+// Sockets without a group are collected under their service in a merged
+// spec, or else under "Ungrouped".
+func Groups(s *spec.Spec) []Group {
+	var groups []Group
+	index := map[string]int{}
+	files := Slugs{"readme": true} // README.md is the index
+	for _, sock := range s.Sockets {
+		name := cmp.Or(sock.Group, sock.Service, "Ungrouped")
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, Group{Name: name, File: files.Slug(name, "group") + ".md"})
+		}
+		groups[i].Sockets = append(groups[i].Sockets, sock)
+	}
+	return groups
+}
+
+var funcs = template.FuncMap{
+	"join":            strings.Join,
+	"cell":            cell,
+	"pretty":          pretty,
+	"compact":         compact,
+	"sequenceDiagram": func(s spec.Socket) string { return diagram.Mermaid(diagram.FromSocket(&s)) },
+	"stateDiagram":    diagram.StateMermaid,
+	"fieldType":       fieldType,
+	"constraints":     constraints,
+}
+
+// Markdown renders one file per socket group plus a README.md index, keyed by file name.
+func Markdown(s *spec.Spec) (map[string][]byte, error) {
+	tmpl, err := template.New("markdown").Funcs(funcs).ParseFS(templates.FS, "markdown/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	groups := Groups(s)
+	files := map[string][]byte{}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "index.md.tmpl", struct {
+		Info    spec.Info
+		Servers []spec.Server
+		Groups  []Group
+	}{s.Info, s.Servers, groups}); err != nil {
+		return nil, err
+	}
+	files["README.md"] = append([]byte(nil), buf.Bytes()...)
+
+	for _, g := range groups {
+		buf.Reset()
+		if err := tmpl.ExecuteTemplate(&buf, "group.md.tmpl", g); err != nil {
+			return nil, fmt.Errorf("group %s: %w", g.Name, err)
+		}
+		files[g.File] = append([]byte(nil), buf.Bytes()...)
+	}
+	return files, nil
+}
+
+// Slug turns a group or socket name into a file name: lowercase letters and
+// digits joined by dashes, so it never contains a path separator or "..".
+func Slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// Slugs hands out file names that differ from each other, also on
+// case-insensitive file systems. It holds the names used so far.
+type Slugs map[string]bool
+
+// Slug returns the Slug of name, or fallback if it has none (e.g. a name
+// without ASCII letters or digits), with a numeric suffix if it is taken.
+func (used Slugs) Slug(name, fallback string) string {
+	base := cmp.Or(Slug(name), fallback)
+	slug := base
+	for n := 2; used[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	used[slug] = true
+	return slug
+}
+
+// cell makes a value safe to put in a Markdown table cell.
+func cell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
+
+// pretty formats a payload or example as indented JSON. Payloads are stored
+// as JSON strings, examples as decoded values.
+func pretty(v interface{}) string {
+	if str, ok := v.(string); ok {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, []byte(str), "", "  "); err == nil {
+			return buf.String()
+		}
+		return str
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// compact formats a value as single-line JSON.
+func compact(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// fieldType names a schema's type for field tables, e.g. string[] for arrays of strings.
+func fieldType(s *spec.Schema) string {
+	switch {
+	case s == nil || s.Type == "":
+		return "any"
+	case s.Type == "array" && s.Items != nil && s.Items.Type != "":
+		return s.Items.Type + "[]"
+	}
+	return s.Type
+}
+
+// constraints summarises a field's format, allowed values, default and bounds.
+func constraints(s *spec.Schema) string {
+	if s == nil {
+		return ""
+	}
+	var c []string
+	if s.Format != "" {
+		c = append(c, "format: "+s.Format)
+	}
+	switch {
+	case len(s.EnumValues) > 0:
+		values := make([]string, len(s.EnumValues))
+		for i, v := range s.EnumValues {
+			values[i] = "`" + compact(v.Value) + "` " + v.Name
+			if v.Description != "" {
+				values[i] += " (" + v.Description + ")"
+			}
+		}
+		c = append(c, "one of: "+strings.Join(values, ", "))
+	case len(s.Enum) > 0:
+		values := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			values[i] = "`" + compact(v) + "`"
+		}
+		c = append(c, "one of: "+strings.Join(values, ", "))
+	}
+	if s.Default != nil {
+		c = append(c, "default: `"+compact(s.Default)+"`")
+	}
+	bound := func(name string, v interface{}) {
+		c = append(c, fmt.Sprintf("%s: %v", name, v))
+	}
+	if s.Minimum != nil {
+		bound("min", *s.Minimum)
+	}
+	if s.Maximum != nil {
+		bound("max", *s.Maximum)
+	}
+	if s.MinLength != nil {
+		bound("min length", *s.MinLength)
+	}
+	if s.MaxLength != nil {
+		bound("max length", *s.MaxLength)
+	}
+	if s.MinItems != nil {
+		bound("min items", *s.MinItems)
+	}
+	if s.MaxItems != nil {
+		bound("max items", *s.MaxItems)
+	}
+	return strings.Join(c, " • ")
+}
@@ -0,0 +1,51 @@
+package docs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/muratmirgun/socketeer/internal/spec"
+)
+
+func TestGroupFiles(t *testing.T) {
+	s := &spec.Spec{Sockets: []spec.Socket{
+		{Name: "a", Group: "Chat"},
+		{Name: "b", Group: "chat!"},
+		{Name: "c", Group: "日本語"},
+		{Name: "d", Group: "中文"},
+		{Name: "e", Group: "Readme"},
+		{Name: "f", Group: "Chat"},
+		{Name: "g"},
+	}}
+	var got []string
+	for _, g := range Groups(s) {
+		got = append(got, g.File)
+	}
+	want := []string{"chat.md", "chat-2.md", "group.md", "group-2.md", "readme-2.md", "ungrouped.md"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("group files = %q, want %q", got, want)
+	}
+
+	files, err := Markdown(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(want)+1 {
+		t.Errorf("Markdown wrote %d files, want %d and README.md", len(files), len(want))
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := map[string]string{
+		"Chat Rooms":     "chat-rooms",
+		"  --Chat--  ":   "chat",
+		"../../etc":      "etc",
+		"v2 / Orders.V1": "v2-orders-v1",
+		"日本語":            "",
+	}
+	for in, want := range tests {
+		if got := Slug(in); got != want {
+			t.Errorf("Slug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
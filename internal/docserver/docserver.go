@@ -0,0 +1,126 @@
+package docserver
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muratmirgun/socketeer/internal/livereload"
+	"github.com/muratmirgun/socketeer/internal/spec"
+	"github.com/muratmirgun/socketeer/internal/templates"
+	"gopkg.in/yaml.v3"
+)
+
+// specMarker is where index.html expects server-provided scripts.
+const specMarker = "<!-- socketeer:spec -->"
+
+// Server serves the documentation UI, the current spec and live reload events.
+// Files in StaticDir override the embedded UI.
+type Server struct {
+	StaticDir string
+	Broker    *livereload.Broker
+	// Proxy relays playground connections; nil disables the proxy endpoint.
+	Proxy *Proxy
+
+	mu     sync.RWMutex
+	spec   []byte
+	parsed *spec.Spec
+}
+
+// New returns a Server for the given static directory (may be empty) with the proxy enabled.
+func New(staticDir string) *Server {
+	s := &Server{StaticDir: staticDir, Broker: livereload.NewBroker()}
+	s.Proxy = NewProxy(s.Parsed)
+	return s
+}
+
+// SetSpec replaces the served spec. If the content changed, open pages are told to reload.
+func (s *Server) SetSpec(data []byte) bool {
+	var parsed spec.Spec
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		parsed = spec.Spec{}
+	}
+	s.mu.Lock()
+	changed := !bytes.Equal(s.spec, data)
+	s.spec = data
+	s.parsed = &parsed
+	s.mu.Unlock()
+	if changed {
+		s.Broker.Notify(time.Now().Format(time.RFC3339))
+	}
+	return changed
+}
+
+// Spec returns the currently served spec.
+func (s *Server) Spec() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.spec
+}
+
+// Parsed returns the currently served spec, decoded.
+func (s *Server) Parsed() *spec.Spec {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.parsed
+}
+
+// Handler returns the HTTP handler for the documentation site.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(livereload.Path, s.Broker)
+	if s.Proxy != nil {
+		mux.Handle(ProxyPath+"/", http.StripPrefix(ProxyPath, s.Proxy))
+	}
+	mux.HandleFunc("/wsapi.yaml", s.serveSpec)
+	mux.HandleFunc("/", s.serveFile)
+	return mux
+}
+
+func (s *Server) serveSpec(w http.ResponseWriter, r *http.Request) {
+	data := s.Spec()
+	if data == nil {
+		http.Error(w, "WebSocket API specification not loaded", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(data)
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "index.html"
+	}
+	data, err := s.readFile(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if name == "index.html" {
+		script := `<script>window.socketeerLiveReload = "` + livereload.Path + `";`
+		if s.Proxy != nil {
+			script += ` window.socketeerProxy = "` + ProxyPath + `";`
+		}
+		script += `</script>`
+		data = bytes.Replace(data, []byte(specMarker), []byte(script), 1)
+	}
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
+
+// readFile reads name from StaticDir, falling back to the embedded templates.
+func (s *Server) readFile(name string) ([]byte, error) {
+	if s.StaticDir != "" {
+		if data, err := os.ReadFile(filepath.Join(s.StaticDir, filepath.FromSlash(name))); err == nil {
+			return data, nil
+		}
+	}
+	return fs.ReadFile(templates.FS, name)
+}
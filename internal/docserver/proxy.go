@@ -0,0 +1,337 @@
+package docserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/muratmirgun/socketeer/internal/diagram"
+	"github.com/muratmirgun/socketeer/internal/spec"
+)
+
+// ProxyPath is the prefix under which the playground proxy is served.
+// Connections go to ProxyPath/<socket name>, recordings are at ProxyPath/_recordings/<client id>.
+const ProxyPath = "/proxy"
+
+// ClientParam is the query parameter the playground uses to tag its connection's recording.
+const ClientParam = "socketeer_client"
+
+// ServerParam selects the server a relative socket URL is resolved against;
+// ServerVarPrefix prefixes the query parameters carrying its variables.
+const (
+	ServerParam     = "socketeer_server"
+	ServerVarPrefix = "socketeer_var_"
+)
+
+const (
+	maxRecordings = 50
+	maxFrames     = 10000
+)
+
+// Proxy relays playground connections to documented sockets. Query parameters
+// documented as `in: header` are sent upstream as request headers, subprotocols
+// are forwarded, and every frame is recorded for export.
+type Proxy struct {
+	// Spec returns the current spec; only sockets documented in it can be proxied.
+	Spec func() *spec.Spec
+	// Upstream is the base URL that relative socket URLs are resolved against.
+	Upstream string
+	// Hosts are the host names the docs server may be reached by; empty allows
+	// any. Restricting them keeps DNS rebinding pages from using the proxy.
+	Hosts []string
+
+	upgrader websocket.Upgrader
+	dialer   websocket.Dialer
+
+	mu         sync.Mutex
+	recordings map[string]*diagram.TrafficExport
+	order      []string
+}
+
+// NewProxy returns a Proxy for the sockets of the spec returned by specFn.
+func NewProxy(specFn func() *spec.Spec) *Proxy {
+	p := &Proxy{
+		Spec:       specFn,
+		dialer:     websocket.Dialer{HandshakeTimeout: 10 * time.Second},
+		recordings: map[string]*diagram.TrafficExport{},
+	}
+	p.upgrader = websocket.Upgrader{CheckOrigin: p.checkOrigin}
+	return p
+}
+
+// checkOrigin accepts requests from pages of the docs server only, so that
+// other sites open in the browser cannot relay connections through it.
+// Requests without an Origin header, e.g. from command-line clients, are accepted.
+func (p *Proxy) checkOrigin(r *http.Request) bool {
+	if len(p.Hosts) > 0 {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = strings.Trim(r.Host, "[]")
+		}
+		if !slices.ContainsFunc(p.Hosts, func(h string) bool { return strings.EqualFold(h, host) }) {
+			return false
+		}
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// ServeHTTP handles /<socket name> connections and /_recordings/<client id> exports.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Checked before dialing upstream; the upgrader would only check after
+	if !p.checkOrigin(r) {
+		http.Error(w, "the proxy only accepts connections from the docs page", http.StatusForbidden)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if id, ok := strings.CutPrefix(name, "_recordings/"); ok {
+		p.serveRecording(w, id)
+		return
+	}
+	socket := p.findSocket(name)
+	if socket == nil {
+		http.Error(w, fmt.Sprintf("socket %q is not documented", name), http.StatusNotFound)
+		return
+	}
+	p.relay(w, r, socket)
+}
+
+func (p *Proxy) findSocket(name string) *spec.Socket {
+	s := p.Spec()
+	if s == nil {
+		return nil
+	}
+	for i := range s.Sockets {
+		if s.Sockets[i].Name == name {
+			return &s.Sockets[i]
+		}
+	}
+	return nil
+}
+
+// upstreamRequest builds the upstream URL and headers from the playground's query parameters.
+func (p *Proxy) upstreamRequest(socket *spec.Socket, query url.Values) (string, http.Header, error) {
+	raw, err := p.resolve(socket, query)
+	if err != nil {
+		return "", nil, err
+	}
+	target, err := url.Parse(raw)
+	if err != nil {
+		return "", nil, err
+	}
+	switch target.Scheme {
+	case "http":
+		target.Scheme = "ws"
+	case "https":
+		target.Scheme = "wss"
+	}
+
+	headers := http.Header{}
+	upstreamQuery := target.Query()
+	in := map[string]string{}
+	for _, param := range socket.ConnectionParams {
+		in[param.Name] = param.In
+	}
+	for key, values := range query {
+		if key == ClientParam || key == ServerParam || strings.HasPrefix(key, ServerVarPrefix) || in[key] == "path" {
+			continue
+		}
+		for _, v := range values {
+			if in[key] == "header" {
+				headers.Add(key, v)
+			} else {
+				upstreamQuery.Add(key, v)
+			}
+		}
+	}
+	target.RawQuery = upstreamQuery.Encode()
+	return target.String(), headers, nil
+}
+
+// resolve returns the absolute URL of the socket with its path parameters filled in.
+// Relative URLs are joined to the server named in the query, to --upstream, or to
+// the socket's first server.
+func (p *Proxy) resolve(socket *spec.Socket, query url.Values) (string, error) {
+	values := map[string]string{}
+	for _, param := range socket.ConnectionParams {
+		if param.In != "path" {
+			continue
+		}
+		if values[param.Name] = query.Get(param.Name); values[param.Name] == "" {
+			return "", fmt.Errorf("path parameter %q is required", param.Name)
+		}
+	}
+	path := socket.ExpandPath(values)
+	if !socket.IsRelative() {
+		return path, nil
+	}
+	name := query.Get(ServerParam)
+	if name == "" && p.Upstream != "" {
+		base, err := url.Parse(p.Upstream)
+		if err != nil {
+			return "", err
+		}
+		ref, err := url.Parse(path)
+		if err != nil {
+			return "", err
+		}
+		return base.ResolveReference(ref).String(), nil
+	}
+	s := p.Spec()
+	if name == "" {
+		if names := socket.ServerNames(s); len(names) > 0 {
+			name = names[0]
+		}
+	}
+	srv := s.Server(name)
+	if srv == nil {
+		if name == "" {
+			return "", fmt.Errorf("socket URL %q is relative; declare a server or start serve with --upstream", socket.URL)
+		}
+		return "", fmt.Errorf("server %q is not documented", name)
+	}
+	vars := map[string]string{}
+	for key := range query {
+		if v, ok := strings.CutPrefix(key, ServerVarPrefix); ok {
+			vars[v] = query.Get(key)
+		}
+	}
+	base, err := srv.Resolve(vars)
+	if err != nil {
+		return "", err
+	}
+	return spec.JoinURL(base, path), nil
+}
+
+func (p *Proxy) relay(w http.ResponseWriter, r *http.Request, socket *spec.Socket) {
+	target, headers, err := p.upstreamRequest(socket, r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	dialer := p.dialer
+	dialer.Subprotocols = websocket.Subprotocols(r)
+	upstream, resp, dialErr := dialer.Dial(target, headers)
+
+	var respHeader http.Header
+	if upstream != nil && upstream.Subprotocol() != "" {
+		respHeader = http.Header{"Sec-Websocket-Protocol": {upstream.Subprotocol()}}
+	}
+	client, err := p.upgrader.Upgrade(w, r, respHeader)
+	if err != nil {
+		if upstream != nil {
+			upstream.Close()
+		}
+		return
+	}
+	defer client.Close()
+
+	if dialErr != nil {
+		reason := dialErr.Error()
+		if resp != nil {
+			reason = "upstream handshake failed: " + resp.Status
+		}
+		if len(reason) > 123 {
+			reason = reason[:123]
+		}
+		client.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, reason), time.Now().Add(time.Second))
+		return
+	}
+	defer upstream.Close()
+
+	rec := p.startRecording(r.URL.Query().Get(ClientParam), socket, target)
+	done := make(chan struct{}, 2)
+	go p.pipe(client, upstream, rec, "out", done)
+	go p.pipe(upstream, client, rec, "in", done)
+	<-done
+}
+
+// pipe copies frames from src to dst, recording them, and forwards the close frame.
+func (p *Proxy) pipe(src, dst *websocket.Conn, rec *diagram.TrafficExport, direction string, done chan<- struct{}) {
+	defer func() { done <- struct{}{} }()
+	for {
+		mt, data, err := src.ReadMessage()
+		if err != nil {
+			code, text := websocket.CloseNormalClosure, ""
+			var ce *websocket.CloseError
+			if errors.As(err, &ce) {
+				code, text = ce.Code, ce.Text
+			}
+			if code == websocket.CloseNoStatusReceived || code == websocket.CloseAbnormalClosure {
+				code = websocket.CloseGoingAway
+			}
+			dst.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(time.Second))
+			return
+		}
+		p.record(rec, direction, data)
+		if err := dst.WriteMessage(mt, data); err != nil {
+			return
+		}
+	}
+}
+
+func (p *Proxy) startRecording(clientID string, socket *spec.Socket, target string) *diagram.TrafficExport {
+	if clientID == "" {
+		clientID = fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	rec := &diagram.TrafficExport{Socket: socket.Name, URL: target}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, exists := p.recordings[clientID]; !exists {
+		p.order = append(p.order, clientID)
+	}
+	p.recordings[clientID] = rec
+	for len(p.order) > maxRecordings {
+		delete(p.recordings, p.order[0])
+		p.order = p.order[1:]
+	}
+	return rec
+}
+
+func (p *Proxy) record(rec *diagram.TrafficExport, direction string, data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(rec.Frames) >= maxFrames {
+		return
+	}
+	rec.Frames = append(rec.Frames, diagram.TrafficRecord{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Direction: direction,
+		Payload:   string(data),
+	})
+}
+
+func (p *Proxy) serveRecording(w http.ResponseWriter, clientID string) {
+	p.mu.Lock()
+	rec, ok := p.recordings[clientID]
+	var data []byte
+	var err error
+	if ok {
+		data, err = json.MarshalIndent(rec, "", "  ")
+	}
+	p.mu.Unlock()
+	if !ok {
+		http.Error(w, "recording not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=websocket-traffic-%s.json", clientID))
+	w.Write(data)
+}
@@ -0,0 +1,267 @@
+// Package lint checks a spec against style rules configured in .socketeer.yaml.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/muratmirgun/socketeer/internal/config"
+	"github.com/muratmirgun/socketeer/internal/spec"
+)
+
+// Severity of a Finding.
+const (
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Finding is a rule violation.
+type Finding struct {
+	Rule     string
+	Severity string
+	Socket   string // socket the finding is about, empty for spec-wide findings
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s (%s)", f.Severity, f.Message, f.Rule)
+}
+
+// Rule is a check run over the whole spec.
+type Rule struct {
+	ID          string
+	Description string
+
+	// check calls report for each finding, with the socket it is about
+	check func(s *spec.Spec, opts config.Rule, report func(socket, format string, args ...interface{}))
+}
+
+var rules = []Rule{
+	{"message-naming", "message types follow the configured naming style (camelCase by default)", checkNaming},
+	{"socket-description", "sockets have a description", checkSocketDescription},
+	{"message-description", "messages have a description", checkMessageDescription},
+	{"param-description", "connection parameters have a description", checkParamDescription},
+	{"send-reply", "every sent message has a reply or documented errors", checkReplies},
+	{"allowed-tags", "socket and message tags are in the allowed list", checkTags},
+	{"unused-component", "declared servers and server variables are used", checkUnused},
+	{"max-payload-depth", "payloads nest at most max levels (5 by default)", checkDepth},
+}
+
+// Rules returns every rule in the order they run.
+func Rules() []Rule {
+	return slices.Clone(rules)
+}
+
+// Run checks the spec with every enabled rule. The configuration is checked
+// first: unknown rule IDs, severities and naming styles are an error.
+func Run(s *spec.Spec, cfg config.Lint) ([]Finding, error) {
+	ids := make([]string, 0, len(cfg.Rules))
+	for id := range cfg.Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		opts := cfg.Rules[id]
+		if !slices.ContainsFunc(rules, func(r Rule) bool { return r.ID == id }) {
+			return nil, fmt.Errorf("unknown lint rule %q", id)
+		}
+		if opts.Severity != "" && opts.Severity != SeverityWarning && opts.Severity != SeverityError {
+			return nil, fmt.Errorf("lint rule %s: severity %q must be warning or error", id, opts.Severity)
+		}
+		if _, ok := namingStyles[opts.Style]; opts.Style != "" && !ok {
+			return nil, fmt.Errorf("lint rule %s: unknown style %q", id, opts.Style)
+		}
+	}
+
+	var findings []Finding
+	for _, r := range rules {
+		opts := cfg.Rules[r.ID]
+		if opts.Enabled != nil && !*opts.Enabled {
+			continue
+		}
+		severity := opts.Severity
+		if severity == "" {
+			severity = SeverityWarning
+		}
+		r.check(s, opts, func(socket, format string, args ...interface{}) {
+			findings = append(findings, Finding{Rule: r.ID, Severity: severity, Socket: socket, Message: fmt.Sprintf(format, args...)})
+		})
+	}
+	return findings, nil
+}
+
+var namingStyles = map[string]*regexp.Regexp{
+	"camelCase":  regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`),
+	"PascalCase": regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`),
+	"snake_case": regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`),
+	"kebab-case": regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`),
+}
+
+func checkNaming(s *spec.Spec, opts config.Rule, report func(string, string, ...interface{})) {
+	style := opts.Style
+	if style == "" {
+		style = "camelCase"
+	}
+	for _, socket := range s.Sockets {
+		seen := map[string]bool{}
+		for _, msg := range socket.Messages {
+			if !seen[msg.Type] && !namingStyles[style].MatchString(msg.Type) {
+				report(socket.Name, "message type %q of %s is not %s", msg.Type, socket.Name, style)
+			}
+			seen[msg.Type] = true
+		}
+	}
+}
+
+func checkSocketDescription(s *spec.Spec, _ config.Rule, report func(string, string, ...interface{})) {
+	for _, socket := range s.Sockets {
+		if socket.Description == "" {
+			report(socket.Name, "socket %s has no description", socket.Name)
+		}
+	}
+}
+
+func checkMessageDescription(s *spec.Spec, _ config.Rule, report func(string, string, ...interface{})) {
+	for _, socket := range s.Sockets {
+		for _, msg := range socket.Messages {
+			if msg.Description == "" {
+				report(socket.Name, "%s message %q of %s has no description", msg.Direction, msg.Type, socket.Name)
+			}
+		}
+	}
+}
+
+func checkParamDescription(s *spec.Spec, _ config.Rule, report func(string, string, ...interface{})) {
+	for _, socket := range s.Sockets {
+		for _, p := range socket.ConnectionParams {
+			if p.Description == "" {
+				report(socket.Name, "connection parameter %q of %s has no description", p.Name, socket.Name)
+			}
+		}
+	}
+}
+
+// checkReplies reports sent messages without a received message of the same
+// type (their reply, as drawn by socketeer diagram) and without errors.
+func checkReplies(s *spec.Spec, _ config.Rule, report func(string, string, ...interface{})) {
+	for _, socket := range s.Sockets {
+		received := map[string]bool{}
+		for _, msg := range socket.Messages {
+			if msg.Direction == "receive" {
+				received[msg.Type] = true
+			}
+		}
+		for _, msg := range socket.Messages {
+			if msg.Direction == "send" && !received[msg.Type] && len(msg.Errors) == 0 {
+				report(socket.Name, "message %q of %s has no reply or documented errors", msg.Type, socket.Name)
+			}
+		}
+	}
+}
+
+func checkTags(s *spec.Spec, opts config.Rule, report func(string, string, ...interface{})) {
+	if len(opts.Allowed) == 0 {
+		return
+	}
+	for _, socket := range s.Sockets {
+		for _, tag := range socket.Tags {
+			if !slices.Contains(opts.Allowed, tag) {
+				report(socket.Name, "tag %q of %s is not allowed", tag, socket.Name)
+			}
+		}
+		for _, msg := range socket.Messages {
+			for _, tag := range msg.Tags {
+				if !slices.Contains(opts.Allowed, tag) {
+					report(socket.Name, "tag %q of message %q of %s is not allowed", tag, msg.Type, socket.Name)
+				}
+			}
+		}
+	}
+}
+
+// checkUnused reports servers no socket can use and server variables missing from their URL.
+// Sockets without a server list use every server.
+func checkUnused(s *spec.Spec, _ config.Rule, report func(string, string, ...interface{})) {
+	used := map[string]bool{}
+	all := false
+	for _, socket := range s.Sockets {
+		all = all || len(socket.Servers) == 0
+		for _, name := range socket.Servers {
+			used[name] = true
+		}
+	}
+	for _, srv := range s.Servers {
+		if !all && !used[srv.Name] {
+			report("", "server %q is not used by any socket", srv.Name)
+		}
+		placeholders := spec.Placeholders(srv.URL)
+		for _, v := range srv.Variables {
+			if !slices.Contains(placeholders, v.Name) {
+				report("", "variable %q of server %q is not used in its URL", v.Name, srv.Name)
+			}
+		}
+	}
+}
+
+func checkDepth(s *spec.Spec, opts config.Rule, report func(string, string, ...interface{})) {
+	limit := opts.Max
+	if limit <= 0 {
+		limit = 5
+	}
+	for _, socket := range s.Sockets {
+		for _, msg := range socket.Messages {
+			if d := payloadDepth(msg); d > limit {
+				report(socket.Name, "payload of %s message %q of %s nests %d levels deep (max %d)", msg.Direction, msg.Type, socket.Name, d, limit)
+			}
+		}
+	}
+}
+
+// payloadDepth returns how deep objects and arrays nest in a message, from
+// its schema or else from its example payload.
+func payloadDepth(msg spec.Message) int {
+	if msg.Schema != nil {
+		return schemaDepth(msg.Schema)
+	}
+	payload := msg.Payload
+	if str, ok := payload.(string); ok {
+		if err := json.Unmarshal([]byte(str), &payload); err != nil {
+			return 0
+		}
+	}
+	return valueDepth(payload)
+}
+
+func schemaDepth(s *spec.Schema) int {
+	if s == nil {
+		return 0
+	}
+	depth := schemaDepth(s.Items)
+	for _, p := range s.Properties {
+		depth = max(depth, schemaDepth(p.Schema))
+	}
+	if s.Type == "object" || s.Type == "array" {
+		depth++
+	}
+	return depth
+}
+
+func valueDepth(v interface{}) int {
+	depth := 0
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, item := range val {
+			depth = max(depth, valueDepth(item))
+		}
+	case []interface{}:
+		for _, item := range val {
+			depth = max(depth, valueDepth(item))
+		}
+	default:
+		return 0
+	}
+	return depth + 1
+}
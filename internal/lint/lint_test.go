@@ -0,0 +1,131 @@
+package lint
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/muratmirgun/socketeer/internal/config"
+	"github.com/muratmirgun/socketeer/internal/spec"
+)
+
+// TestRules runs each rule on testdata/<rule>.pass.yaml, which must have no
+// finding of that rule, and testdata/<rule>.fail.yaml.
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule string
+		opts config.Rule
+		want []string // messages of the findings in the failing fixture
+	}{
+		{"message-naming", config.Rule{}, []string{
+			`message type "join_room" of Room is not camelCase`,
+			`message type "LeaveRoom" of Room is not camelCase`,
+		}},
+		{"socket-description", config.Rule{}, []string{"socket Room has no description"}},
+		{"message-description", config.Rule{}, []string{`receive message "join" of Room has no description`}},
+		{"param-description", config.Rule{}, []string{`connection parameter "token" of Room has no description`}},
+		{"send-reply", config.Rule{}, []string{`message "join" of Room has no reply or documented errors`}},
+		{"allowed-tags", config.Rule{Allowed: []string{"chat", "public"}}, []string{
+			`tag "beta" of Room is not allowed`,
+			`tag "internal" of message "join" of Room is not allowed`,
+		}},
+		{"unused-component", config.Rule{}, []string{
+			`variable "region" of server "production" is not used in its URL`,
+			`server "staging" is not used by any socket`,
+		}},
+		{"max-payload-depth", config.Rule{Max: 2}, []string{
+			`payload of send message "join" of Room nests 4 levels deep (max 2)`,
+			`payload of send message "leave" of Room nests 3 levels deep (max 2)`,
+		}},
+	}
+	if len(tests) != len(Rules()) {
+		t.Errorf("%d rules tested, want all %d", len(tests), len(Rules()))
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			cfg := config.Lint{Rules: map[string]config.Rule{tt.rule: tt.opts}}
+			for _, fixture := range []string{"pass", "fail"} {
+				s, err := spec.Load(filepath.Join("testdata", tt.rule+"."+fixture+".yaml"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				findings, err := Run(s, cfg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got []string
+				for _, f := range findings {
+					if f.Rule == tt.rule {
+						got = append(got, f.Message)
+					}
+				}
+				var want []string
+				if fixture == "fail" {
+					want = tt.want
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s findings on the %s fixture = %q, want %q", tt.rule, fixture, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunOptions(t *testing.T) {
+	s, err := spec.Load("testdata/message-naming.fail.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	disabled := false
+	tests := []struct {
+		name string
+		rule config.Rule
+		want []string
+	}{
+		{"default severity", config.Rule{}, []string{
+			`warning: message type "join_room" of Room is not camelCase (message-naming)`,
+			`warning: message type "LeaveRoom" of Room is not camelCase (message-naming)`,
+		}},
+		{"error severity and style", config.Rule{Severity: SeverityError, Style: "snake_case"}, []string{
+			`error: message type "LeaveRoom" of Room is not snake_case (message-naming)`,
+		}},
+		{"disabled", config.Rule{Enabled: &disabled}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := Run(s, config.Lint{Rules: map[string]config.Rule{"message-naming": tt.rule}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, f := range findings {
+				if f.Rule == "message-naming" {
+					got = append(got, f.String())
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunConfigErrors(t *testing.T) {
+	tests := map[string]struct {
+		rules map[string]config.Rule
+		want  string
+	}{
+		"unknown rule":     {map[string]config.Rule{"no-such-rule": {}}, `unknown lint rule "no-such-rule"`},
+		"unknown severity": {map[string]config.Rule{"send-reply": {Severity: "fatal"}}, `lint rule send-reply: severity "fatal" must be warning or error`},
+		"unknown style":    {map[string]config.Rule{"message-naming": {Style: "SCREAMING"}}, `lint rule message-naming: unknown style "SCREAMING"`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Run(&spec.Spec{}, config.Lint{Rules: tt.rules})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Run error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package livereload
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Path is where documentation pages look for reload events.
+const Path = "/livereload"
+
+// Broker fans out reload events to connected server-sent-events clients.
+type Broker struct {
+	mu      sync.Mutex
+	clients map[chan string]struct{}
+	done    chan struct{}
+	closed  bool
+}
+
+// NewBroker returns a Broker with no clients.
+func NewBroker() *Broker {
+	return &Broker{clients: map[chan string]struct{}{}, done: make(chan struct{})}
+}
+
+// Close ends all event streams, e.g. so a graceful server shutdown does not wait on them.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+}
+
+// Notify sends a reload event with the given data to every connected client.
+// Slow clients miss events rather than blocking the caller.
+func (b *Broker) Notify(data string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.clients {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// ServeHTTP streams reload events until the client disconnects.
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	ch := make(chan string, 1)
+	b.mu.Lock()
+	b.clients[ch] = struct{}{}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.clients, ch)
+		b.mu.Unlock()
+	}()
+
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-b.done:
+			return
+		case data := <-ch:
+			fmt.Fprintf(w, "event: reload\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// annotation is one `@Name args...` line of a block together with its continuation lines.
+//
+// The grammar, also published in the README:
+//
+//	block        = { annotation | other-line } .
+//	annotation   = "@" name { token } newline { continuation } .
+//	continuation = text-line | fence .
+//	fence        = "```" [ lang ] newline { any-line } "```" newline .
+//	token        = word | quoted | option .
+//	quoted       = `"` { char | `\"` } `"` | "'" { char } "'" .
+//	option       = key "=" ( word | quoted ) .
+//
+// Continuation lines are the comment lines after an annotation up to the next
+// annotation or a blank comment line; fenced blocks may contain both.
+// Options are only recognised for the keys an annotation accepts (see annotationOptions).
+type annotation struct {
+	Name string
+	raw  string   // text after the name on the first line
+	args []string // positional arguments, unquoted
+	opts map[string]string
+	more []string // continuation lines
+}
+
+// annotationOptions lists the key=value options each annotation accepts.
+// Every annotation accepts description=.
+var annotationOptions = map[string][]string{
+	"@ConnectionParam": {"in", "type", "required"},
+	"@Heartbeat":       {"interval", "timeout", "message"},
+	"@Message":         {"deprecated", "tags"},
+}
+
+// parseAnnotations splits a block into annotations. Lines before the first annotation are ignored.
+func parseAnnotations(block []string) []annotation {
+	var anns []annotation
+	var cur *annotation
+	inFence, ended := false, false
+	for _, line := range block {
+		t := strings.TrimSpace(line)
+		switch {
+		case inFence:
+			cur.more = append(cur.more, line)
+			if strings.HasPrefix(t, "```") {
+				inFence = false
+			}
+		case isAnnotationLine(t):
+			anns = append(anns, newAnnotation(t))
+			cur, ended = &anns[len(anns)-1], false
+		case cur == nil || ended:
+		case t == "":
+			ended = true
+		default:
+			cur.more = append(cur.more, line)
+			inFence = strings.HasPrefix(t, "```")
+		}
+	}
+	return anns
+}
+
+// isAnnotationLine reports whether a trimmed comment line starts an annotation.
+func isAnnotationLine(t string) bool {
+	return len(t) > 1 && t[0] == '@' && unicode.IsLetter(rune(t[1]))
+}
+
+func newAnnotation(line string) annotation {
+	name, raw := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name, raw = line[:i], line[i+1:]
+	}
+	a := annotation{Name: name, raw: strings.TrimSpace(raw), opts: map[string]string{}}
+	allowed := map[string]bool{"description": true}
+	for _, k := range annotationOptions[name] {
+		allowed[k] = true
+	}
+	for _, tok := range tokenize(a.raw) {
+		if !tok.quoted {
+			if k, v, ok := strings.Cut(tok.text, "="); ok && allowed[k] {
+				a.opts[k] = unquote(v)
+				continue
+			}
+		}
+		a.args = append(a.args, tok.value)
+	}
+	return a
+}
+
+// lexeme is one whitespace-separated token of an annotation line.
+type lexeme struct {
+	text   string // as written
+	value  string // quotes removed
+	quoted bool
+}
+
+// tokenize splits s on whitespace, keeping quoted strings together. An
+// unterminated quote runs to the end of the line.
+func tokenize(s string) []lexeme {
+	var toks []lexeme
+	i := 0
+	for i < len(s) {
+		for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+			i++
+		}
+		if i == len(s) {
+			break
+		}
+		start := i
+		var val strings.Builder
+		quoted := false
+		for i < len(s) && s[i] != ' ' && s[i] != '\t' {
+			if q := s[i]; q == '"' || q == '\'' {
+				quoted = quoted || i == start
+				i++
+				for i < len(s) && s[i] != q {
+					if q == '"' && s[i] == '\\' && i+1 < len(s) {
+						i++
+					}
+					val.WriteByte(s[i])
+					i++
+				}
+				i++ // closing quote
+				continue
+			}
+			val.WriteByte(s[i])
+			i++
+		}
+		toks = append(toks, lexeme{text: s[start:min(i, len(s))], value: val.String(), quoted: quoted})
+	}
+	return toks
+}
+
+func unquote(s string) string {
+	if toks := tokenize(s); len(toks) == 1 {
+		return toks[0].value
+	}
+	return s
+}
+
+func (a annotation) arg(i int) string {
+	if i < len(a.args) {
+		return a.args[i]
+	}
+	return ""
+}
+
+func (a annotation) opt(key string) (string, bool) {
+	v, ok := a.opts[key]
+	return v, ok
+}
+
+// rest returns the arguments from i on as free text, followed by the continuation
+// lines. A description= option takes precedence.
+func (a annotation) rest(i int) string {
+	if d, ok := a.opt("description"); ok {
+		return d
+	}
+	var first string
+	if i < len(a.args) {
+		first = strings.Join(a.args[i:], " ")
+	}
+	return joinText(first, a.continuation())
+}
+
+// text returns the first line as written followed by the continuation lines,
+// e.g. for Markdown descriptions.
+func (a annotation) text() string {
+	if d, ok := a.opt("description"); ok {
+		return d
+	}
+	return joinText(a.raw, a.continuation())
+}
+
+// value returns the annotation's value for payloads: the content of a fenced
+// block if there is one, else the first line followed by the continuation lines.
+// The second result is the fence's language.
+func (a annotation) value() (string, string) {
+	if body, lang, ok := a.fence(); ok {
+		return body, lang
+	}
+	return joinText(a.raw, a.continuation()), ""
+}
+
+// fence returns the content and language of a fenced block starting the continuation lines.
+func (a annotation) fence() (string, string, bool) {
+	more := a.continuation()
+	if !strings.HasPrefix(more, "```") {
+		return "", "", false
+	}
+	lang, rest, _ := strings.Cut(more, "\n")
+	body, _, _ := strings.Cut(rest, "```")
+	return strings.TrimSpace(body), strings.TrimSpace(strings.TrimPrefix(lang, "```")), true
+}
+
+// continuation returns the continuation lines with their common indentation removed.
+func (a annotation) continuation() string {
+	if len(a.more) == 0 {
+		return ""
+	}
+	indent := -1
+	for _, l := range a.more {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	lines := make([]string, len(a.more))
+	for i, l := range a.more {
+		if len(l) >= indent {
+			lines[i] = strings.TrimRight(l[indent:], " \t")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func joinText(first, more string) string {
+	switch {
+	case more == "":
+		return first
+	case first == "":
+		return more
+	}
+	return first + "\n" + more
+}
+
+// decodeValue decodes an inline value written as JSON, or as YAML in a ```yaml fence.
+func decodeValue(text, lang string) (interface{}, bool) {
+	var v interface{}
+	switch lang {
+	case "yaml", "yml":
+		if err := yaml.Unmarshal([]byte(text), &v); err != nil {
+			return nil, false
+		}
+	default:
+		if err := json.Unmarshal([]byte(text), &v); err != nil {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// decodePayload decodes an inline payload and returns it re-encoded as compact JSON.
+func decodePayload(text, lang string) (string, bool) {
+	v, ok := decodeValue(text, lang)
+	if !ok {
+		return "", false
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
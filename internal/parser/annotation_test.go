@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []lexeme
+	}{
+		{"", nil},
+		{"  \t ", nil},
+		{"word", []lexeme{{text: "word", value: "word"}}},
+		{" a\tb  c ", []lexeme{{text: "a", value: "a"}, {text: "b", value: "b"}, {text: "c", value: "c"}}},
+		{`"Chat Rooms"`, []lexeme{{text: `"Chat Rooms"`, value: "Chat Rooms", quoted: true}}},
+		{`'single quoted'`, []lexeme{{text: `'single quoted'`, value: "single quoted", quoted: true}}},
+		{`"say \"hi\""`, []lexeme{{text: `"say \"hi\""`, value: `say "hi"`, quoted: true}}},
+		// Backslashes only escape in double quotes
+		{`'a\b'`, []lexeme{{text: `'a\b'`, value: `a\b`, quoted: true}}},
+		// A quote inside a word keeps the word together, but the token is not quoted
+		{`key="a b" next`, []lexeme{{text: `key="a b"`, value: "key=a b"}, {text: "next", value: "next"}}},
+		{`"unterminated quote`, []lexeme{{text: `"unterminated quote`, value: "unterminated quote", quoted: true}}},
+		{`"" x`, []lexeme{{text: `""`, value: "", quoted: true}, {text: "x", value: "x"}}},
+	}
+	for _, tt := range tests {
+		if got := tokenize(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewAnnotation(t *testing.T) {
+	tests := []struct {
+		line string
+		want annotation
+	}{
+		{"@WebSocket", annotation{Name: "@WebSocket", opts: map[string]string{}}},
+		{"@WebSocket Chat", annotation{Name: "@WebSocket", raw: "Chat", args: []string{"Chat"}, opts: map[string]string{}}},
+		{`@Group "Chat Rooms"`, annotation{Name: "@Group", raw: `"Chat Rooms"`, args: []string{"Chat Rooms"}, opts: map[string]string{}}},
+		{
+			`@ConnectionParam token in=header type=string required=true description="JWT token"`,
+			annotation{
+				Name: "@ConnectionParam", raw: `token in=header type=string required=true description="JWT token"`,
+				args: []string{"token"},
+				opts: map[string]string{"in": "header", "type": "string", "required": "true", "description": "JWT token"},
+			},
+		},
+		// Only the keys an annotation accepts are options
+		{
+			"@Message join in=header tags=a,b",
+			annotation{Name: "@Message", raw: "join in=header tags=a,b", args: []string{"join", "in=header"}, opts: map[string]string{"tags": "a,b"}},
+		},
+		// A quoted token is never an option
+		{
+			`@Description "description=not an option"`,
+			annotation{Name: "@Description", raw: `"description=not an option"`, args: []string{"description=not an option"}, opts: map[string]string{}},
+		},
+		{"@URL\t/ws/chat", annotation{Name: "@URL", raw: "/ws/chat", args: []string{"/ws/chat"}, opts: map[string]string{}}},
+	}
+	for _, tt := range tests {
+		if got := newAnnotation(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("newAnnotation(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	type ann struct {
+		Name string
+		Args []string
+		More []string
+	}
+	tests := []struct {
+		name  string
+		block []string
+		want  []ann
+	}{
+		{
+			name:  "other lines before the first annotation are ignored",
+			block: []string{"ChatHandler handles chat.", "", "@WebSocket Chat"},
+			want:  []ann{{Name: "@WebSocket", Args: []string{"Chat"}}},
+		},
+		{
+			name:  "continuation lines end at the next annotation",
+			block: []string{"@Description Real-time **chat**.", "  - join rooms", "  - send messages", "@URL /ws"},
+			want: []ann{
+				{Name: "@Description", Args: []string{"Real-time", "**chat**."}, More: []string{"  - join rooms", "  - send messages"}},
+				{Name: "@URL", Args: []string{"/ws"}},
+			},
+		},
+		{
+			name:  "continuation lines end at a blank line",
+			block: []string{"@Description First", "second", "", "not part of it", "@URL /ws"},
+			want: []ann{
+				{Name: "@Description", Args: []string{"First"}, More: []string{"second"}},
+				{Name: "@URL", Args: []string{"/ws"}},
+			},
+		},
+		{
+			name:  "fences keep blank lines and annotation-like lines",
+			block: []string{"@Payload", "```yaml", "text: hi", "", "@mention: true", "```", "@Send"},
+			want: []ann{
+				{Name: "@Payload", More: []string{"```yaml", "text: hi", "", "@mention: true", "```"}},
+				{Name: "@Send"},
+			},
+		},
+		{
+			name:  "an email address is not an annotation",
+			block: []string{"@Description Contact", "@ @1 not annotations either"},
+			want:  []ann{{Name: "@Description", Args: []string{"Contact"}, More: []string{"@ @1 not annotations either"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []ann
+			for _, a := range parseAnnotations(tt.block) {
+				got = append(got, ann{a.Name, a.args, a.more})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAnnotations(%q) = %+v, want %+v", tt.block, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnnotationText(t *testing.T) {
+	a := parseAnnotations([]string{"@Description Real-time **chat**.", "    - join rooms", "      - nested", "    - send messages"})[0]
+	want := "Real-time **chat**.\n- join rooms\n  - nested\n- send messages"
+	if got := a.text(); got != want {
+		t.Errorf("text() = %q, want %q", got, want)
+	}
+
+	a = parseAnnotations([]string{"@Payload", "  ```json", `  {"a": 1}`, "  ```"})[0]
+	body, lang, ok := a.fence()
+	if !ok || lang != "json" || body != `{"a": 1}` {
+		t.Errorf("fence() = %q, %q, %v", body, lang, ok)
+	}
+}
@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+)
+
+// node is a declaration annotation blocks can be attached to: a function, a
+// method, a type or the package clause.
+type node struct {
+	key  string // receiver-qualified name, e.g. "ChatHandler.ServeHTTP"; "package" for the package clause
+	name string // name routes refer to: the function, method or type name
+	pos  token.Pos
+	end  token.Pos
+}
+
+// fileNodes returns the attachable declarations of a file in source order.
+// Var, const and import declarations are skipped, so comments above them
+// attach to the next function or type as before.
+func fileNodes(file *ast.File) []node {
+	nodes := []node{{key: "package", name: file.Name.Name, pos: file.Package, end: file.Name.End()}}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			nodes = append(nodes, node{key: funcKey(d), name: d.Name.Name, pos: d.Pos(), end: d.End()})
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range d.Specs {
+				ts := s.(*ast.TypeSpec)
+				pos := ts.Pos()
+				if !d.Lparen.IsValid() {
+					pos = d.Pos()
+				}
+				nodes = append(nodes, node{key: ts.Name.Name, name: ts.Name.Name, pos: pos, end: ts.End()})
+			}
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].pos < nodes[j].pos })
+	return nodes
+}
+
+// attach returns the node a comment group belongs to: the declaration it is
+// inside of, else the next declaration. Comments above the package clause
+// belong to the package. ok is false for comments after the last declaration.
+func attach(nodes []node, cg *ast.CommentGroup) (node, bool) {
+	for _, n := range nodes {
+		if n.pos <= cg.Pos() && cg.End() <= n.end {
+			return n, true
+		}
+	}
+	for _, n := range nodes {
+		if n.pos > cg.End() {
+			return n, true
+		}
+	}
+	return node{}, false
+}
+
+// funcKey returns Name for functions and Recv.Name for methods.
+func funcKey(fn *ast.FuncDecl) string {
+	if recv := recvType(fn); recv != "" {
+		return recv + "." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
+
+// recvType returns the receiver type name of a method, without pointer or type parameters.
+func recvType(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	t := fn.Recv.List[0].Type
+	for {
+		switch e := t.(type) {
+		case *ast.StarExpr:
+			t = e.X
+		case *ast.IndexExpr:
+			t = e.X
+		case *ast.IndexListExpr:
+			t = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
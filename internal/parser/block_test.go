@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestParseSocketBlock parses the comment groups in testdata/blocks/*.txt and
+// compares the sockets with the .golden.yaml file next to each.
+// Run with -update after intended changes.
+func TestParseSocketBlock(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/blocks/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, in := range inputs {
+		t.Run(strings.TrimSuffix(filepath.Base(in), ".txt"), func(t *testing.T) {
+			src, err := os.ReadFile(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var comments []*ast.Comment
+			for _, line := range strings.Split(strings.TrimSuffix(string(src), "\n"), "\n") {
+				comments = append(comments, &ast.Comment{Text: line})
+			}
+			socket := parseSocketBlock(extractAnnotationBlock(comments), nil)
+			got, err := yaml.Marshal(socket)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := strings.TrimSuffix(in, ".txt") + ".golden.yaml"
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("socket of %s differs from %s:\n%s", in, golden, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,460 @@
+package parser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/muratmirgun/socketeer/internal/spec"
+	"github.com/muratmirgun/socketeer/internal/version"
+)
+
+// cacheVersion is bumped whenever the parse results or their encoding change,
+// invalidating every cache entry.
+const cacheVersion = 2
+
+func init() {
+	// Example values, enum values and schema defaults are stored as interfaces
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+	gob.Register(emptyValue{})
+}
+
+// emptyValue stands in for an empty list or object, which gob would decode as nil.
+type emptyValue struct {
+	Map bool
+}
+
+// cacheEntry is the parse result of one file, valid while the file and the
+// files it read have the same content.
+type cacheEntry struct {
+	Path string
+	Hash string
+	Deps map[string]string // content hash by path, empty for a missing file
+	File cachedFile
+}
+
+// cachedFile mirrors fileResult with exported fields. Function bodies are
+// not kept, so cached results cannot be used for inference.
+type cachedFile struct {
+	Blocks    []cachedBlock
+	Fragments []cachedBlock
+	Structs   map[string]cachedStruct
+	Enums     map[string][]spec.EnumValue
+	Marshals  map[string]string
+	Schemas   map[string]*spec.Schema
+	Deps      []string
+	Diags     []Diagnostic
+	Generated bool
+	Info      []string
+	Routes    []cachedRoute
+	Upgraders []cachedHandler
+	Annotated map[string]bool
+	Consts    map[string]string
+}
+
+type cachedBlock struct {
+	Handler, Name string
+	Pos           token.Position
+	Lines         []string
+	Ref           string
+	Ignore        []string
+}
+
+type cachedStruct struct {
+	Struct     bool // Fields is not nil; gob decodes empty maps as nil
+	Fields     map[string]interface{}
+	Order      []string
+	Typed      []cachedField
+	Basic      string
+	Enum       []spec.EnumValue
+	Underlying string // Go source of the type expression
+	Marshal    string
+	Override   *spec.Schema
+}
+
+type cachedField struct {
+	Name, Type, Tag, Doc                               string
+	Deprecated, Example, Embedded, OmitEmpty, AsString bool
+}
+
+type cachedRoute struct {
+	Path, Handler string
+	Pos           token.Position
+}
+
+type cachedHandler struct {
+	Name, Recv string
+	Pos        token.Position
+}
+
+// cacheKey is the version a cache file must have: the cache format and the socketeer build.
+func cacheKey() string {
+	return fmt.Sprintf("%s/%d", version.Version, cacheVersion)
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fileHash returns the content hash of a file, or "" if it cannot be read.
+func fileHash(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return contentHash(data)
+}
+
+// parseCache holds the cache entries of an index. They are stored together in
+// one file per set of source directories: decoding a gob stream per source
+// file would take longer than parsing it.
+type parseCache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry // by source path
+	used    map[string]bool
+	dirty   bool
+}
+
+// cacheBundle is the content of a cache file.
+type cacheBundle struct {
+	Version string
+	Entries map[string]cacheEntry
+}
+
+// openCache reads the cache of an index over roots from dir. Any problem with
+// the cache file is treated as an empty cache.
+func openCache(dir string, roots []string) *parseCache {
+	var key []string
+	for _, root := range roots {
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+		key = append(key, root)
+	}
+	c := &parseCache{
+		path:    filepath.Join(dir, contentHash([]byte(strings.Join(key, "\n")))+".gob"),
+		entries: map[string]cacheEntry{},
+		used:    map[string]bool{},
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return c
+	}
+	var b cacheBundle
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&b); err == nil && b.Version == cacheKey() {
+		c.entries = b.Entries
+	}
+	return c
+}
+
+// get returns the cached parse result of a file with content src, if any.
+func (c *parseCache) get(path string, src []byte) (*fileResult, bool) {
+	c.mu.Lock()
+	e, ok := c.entries[path]
+	c.used[path] = true
+	c.mu.Unlock()
+	if !ok || e.Hash != contentHash(src) {
+		return nil, false
+	}
+	for dep, hash := range e.Deps {
+		if fileHash(dep) != hash {
+			return nil, false
+		}
+	}
+	result, err := e.File.result()
+	if err != nil {
+		return nil, false
+	}
+	return result, true
+}
+
+// put stores the parse result of a file with content src.
+func (c *parseCache) put(path string, src []byte, result *fileResult) {
+	e := cacheEntry{Path: path, Hash: contentHash(src), Deps: map[string]string{}, File: cacheFile(result)}
+	for _, dep := range result.deps {
+		e.Deps[dep] = fileHash(dep)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[path] = e
+	c.used[path] = true
+	c.dirty = true
+}
+
+// save writes the cache file if an entry changed. With prune, entries of
+// files not looked up since the cache was opened are dropped, e.g. of
+// deleted files. Errors are ignored: the files are parsed again next time.
+func (c *parseCache) save(prune bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prune {
+		for path := range c.entries {
+			if !c.used[path] {
+				delete(c.entries, path)
+				c.dirty = true
+			}
+		}
+	}
+	if !c.dirty {
+		return
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cacheBundle{Version: cacheKey(), Entries: c.entries}); err != nil {
+		return
+	}
+	dir := filepath.Dir(c.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return
+	}
+	// Written to a temporary file first so that concurrent runs never read a partial file
+	tmp, err := os.CreateTemp(dir, "*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(buf.Bytes())
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	c.dirty = false
+}
+
+func cacheFile(r *fileResult) cachedFile {
+	f := cachedFile{
+		Blocks:    cacheBlocks(r.blocks),
+		Fragments: cacheBlocks(r.fragments),
+		Structs:   map[string]cachedStruct{},
+		Enums:     map[string][]spec.EnumValue{},
+		Marshals:  r.marshals,
+		Schemas:   map[string]*spec.Schema{},
+		Deps:      r.deps,
+		Diags:     r.diags,
+		Generated: r.generated,
+		Info:      r.info,
+		Annotated: r.annotated,
+		Consts:    r.consts,
+	}
+	for name, values := range r.enums {
+		f.Enums[name] = mapEnum(values, packValue)
+	}
+	for name, schema := range r.schemas {
+		f.Schemas[name] = mapSchema(schema, packValue)
+	}
+	for name, s := range r.structs {
+		cs := cachedStruct{
+			Struct: s.isStruct(), Order: s.Order, Basic: s.basic, Enum: mapEnum(s.enum, packValue),
+			Underlying: exprString(s.underlying), Marshal: s.marshal, Override: mapSchema(s.override, packValue),
+		}
+		if s.Fields != nil {
+			cs.Fields = map[string]interface{}{}
+			for k, v := range s.Fields {
+				cs.Fields[k] = packValue(v)
+			}
+		}
+		for _, sf := range s.fields {
+			cs.Typed = append(cs.Typed, cachedField{
+				Name: sf.name, Type: exprString(sf.typ), Tag: string(sf.tag), Doc: sf.doc,
+				Deprecated: sf.deprecated, Example: sf.example, Embedded: sf.embedded, OmitEmpty: sf.omitempty, AsString: sf.asString,
+			})
+		}
+		f.Structs[name] = cs
+	}
+	for _, rt := range r.routes {
+		f.Routes = append(f.Routes, cachedRoute{rt.path, rt.handler, rt.pos})
+	}
+	for _, h := range r.upgraders {
+		f.Upgraders = append(f.Upgraders, cachedHandler{h.name, h.recv, h.pos})
+	}
+	return f
+}
+
+func cacheBlocks(blocks []socketBlock) []cachedBlock {
+	var out []cachedBlock
+	for _, b := range blocks {
+		out = append(out, cachedBlock{b.handler, b.name, b.pos, b.lines, b.ref, b.ignore})
+	}
+	return out
+}
+
+func (f cachedFile) result() (*fileResult, error) {
+	r := &fileResult{
+		blocks:    uncacheBlocks(f.Blocks),
+		fragments: uncacheBlocks(f.Fragments),
+		structs:   map[string]StructInfo{},
+		enums:     map[string][]spec.EnumValue{},
+		marshals:  f.Marshals,
+		schemas:   map[string]*spec.Schema{},
+		deps:      f.Deps,
+		diags:     f.Diags,
+		generated: f.Generated,
+		info:      f.Info,
+		annotated: f.Annotated,
+		consts:    f.Consts,
+	}
+	for name, values := range f.Enums {
+		r.enums[name] = mapEnum(values, unpackValue)
+	}
+	for name, schema := range f.Schemas {
+		r.schemas[name] = mapSchema(schema, unpackValue)
+	}
+	for name, cs := range f.Structs {
+		s := StructInfo{Order: cs.Order, basic: cs.Basic, enum: mapEnum(cs.Enum, unpackValue), marshal: cs.Marshal, override: mapSchema(cs.Override, unpackValue)}
+		if cs.Struct {
+			s.Fields = map[string]interface{}{}
+			for k, v := range cs.Fields {
+				s.Fields[k] = unpackValue(v)
+			}
+		}
+		var err error
+		if s.underlying, err = parseExpr(cs.Underlying); err != nil {
+			return nil, err
+		}
+		for _, cf := range cs.Typed {
+			sf := structField{
+				name: cf.Name, tag: reflect.StructTag(cf.Tag), doc: cf.Doc,
+				deprecated: cf.Deprecated, example: cf.Example, embedded: cf.Embedded, omitempty: cf.OmitEmpty, asString: cf.AsString,
+			}
+			if sf.typ, err = parseExpr(cf.Type); err != nil {
+				return nil, err
+			}
+			s.fields = append(s.fields, sf)
+		}
+		r.structs[name] = s
+	}
+	for _, rt := range f.Routes {
+		r.routes = append(r.routes, route{rt.Path, rt.Handler, rt.Pos})
+	}
+	for _, h := range f.Upgraders {
+		r.upgraders = append(r.upgraders, handlerFunc{h.Name, h.Recv, h.Pos})
+	}
+	return r, nil
+}
+
+func uncacheBlocks(blocks []cachedBlock) []socketBlock {
+	var out []socketBlock
+	for _, b := range blocks {
+		out = append(out, socketBlock{handler: b.Handler, name: b.Name, pos: b.Pos, lines: b.Lines, ref: b.Ref, ignore: b.Ignore})
+	}
+	return out
+}
+
+// exprString returns the Go source of a type expression, or "" for nil.
+func exprString(expr ast.Expr) string {
+	if expr == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	printer.Fprint(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
+
+func parseExpr(src string) (ast.Expr, error) {
+	if src == "" {
+		return nil, nil
+	}
+	// Most field types are a plain name, which needs no parser
+	if token.IsIdentifier(src) {
+		return ast.NewIdent(src), nil
+	}
+	return parser.ParseExpr(src)
+}
+
+// packValue replaces the empty lists and objects in a decoded JSON or YAML value with emptyValue.
+func packValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case []interface{}:
+		if val != nil && len(val) == 0 {
+			return emptyValue{}
+		}
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = packValue(item)
+		}
+		return out
+	case map[string]interface{}:
+		if val != nil && len(val) == 0 {
+			return emptyValue{Map: true}
+		}
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = packValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// unpackValue reverses packValue.
+func unpackValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case emptyValue:
+		if val.Map {
+			return map[string]interface{}{}
+		}
+		return []interface{}{}
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = unpackValue(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = unpackValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+func mapEnum(values []spec.EnumValue, f func(interface{}) interface{}) []spec.EnumValue {
+	var out []spec.EnumValue
+	for _, v := range values {
+		v.Value = f(v.Value)
+		out = append(out, v)
+	}
+	return out
+}
+
+// mapSchema returns a copy of a schema with f applied to its default and enum values.
+func mapSchema(s *spec.Schema, f func(interface{}) interface{}) *spec.Schema {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.Default = f(c.Default)
+	c.Enum = nil
+	for _, v := range s.Enum {
+		c.Enum = append(c.Enum, f(v))
+	}
+	c.EnumValues = mapEnum(s.EnumValues, f)
+	c.Items = mapSchema(s.Items, f)
+	c.Properties = nil
+	for _, p := range s.Properties {
+		c.Properties = append(c.Properties, spec.Property{Name: p.Name, Schema: mapSchema(p.Schema, f)})
+	}
+	return &c
+}
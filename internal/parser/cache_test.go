@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/muratmirgun/socketeer/internal/version"
+	"gopkg.in/yaml.v3"
+)
+
+// copyFixture copies testdata/cache into a temporary directory the test may change.
+func copyFixture(t testing.TB) string {
+	t.Helper()
+	dir := t.TempDir()
+	err := filepath.WalkDir("testdata/cache", func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel("testdata/cache", path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestPackValue(t *testing.T) {
+	values := []interface{}{
+		nil,
+		"text",
+		1.5,
+		[]interface{}{},
+		map[string]interface{}{},
+		[]interface{}{"a", []interface{}{}, map[string]interface{}{}},
+		map[string]interface{}{"tags": []interface{}{}, "labels": map[string]interface{}{}, "n": 1},
+	}
+	for _, v := range values {
+		// Stored inside an interface, as in StructInfo.Fields and schema defaults
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(struct{ V interface{} }{packValue(v)}); err != nil {
+			t.Fatalf("encoding %#v: %v", v, err)
+		}
+		var got struct{ V interface{} }
+		if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+			t.Fatalf("decoding %#v: %v", v, err)
+		}
+		if u := unpackValue(got.V); !reflect.DeepEqual(u, v) {
+			t.Errorf("round trip of %#v = %#v", v, u)
+		}
+	}
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	dir := copyFixture(t)
+	cacheDir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := parseFile(path, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := openCache(cacheDir, []string{dir})
+	cache.put(path, src, result)
+	cache.save(false)
+	cached, ok := openCache(cacheDir, []string{dir}).get(path, src)
+	if !ok {
+		t.Fatal("get missed after put and save")
+	}
+	if want, got := cacheFile(result), cacheFile(cached); !reflect.DeepEqual(got, want) {
+		t.Errorf("cached result differs:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+// TestCachedSpec checks that specs generated from a cold and a warm cache
+// match the one generated without a cache.
+func TestCachedSpec(t *testing.T) {
+	dir := copyFixture(t)
+	generate := func(cacheDir string) []byte {
+		t.Helper()
+		ix := NewIndex(dir)
+		ix.CacheDir = cacheDir
+		if err := ix.Load(); err != nil {
+			t.Fatal(err)
+		}
+		data, err := yaml.Marshal(ix.Spec())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	want := generate("")
+	cacheDir := t.TempDir()
+	if cold := generate(cacheDir); !bytes.Equal(cold, want) {
+		t.Errorf("spec with a cold cache differs:\n%s\nwant\n%s", cold, want)
+	}
+	if warm := generate(cacheDir); !bytes.Equal(warm, want) {
+		t.Errorf("spec with a warm cache differs:\n%s\nwant\n%s", warm, want)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	// Each change returns the source of main.go afterwards
+	tests := []struct {
+		name   string
+		change func(t *testing.T, dir string, src []byte) []byte
+	}{
+		{"source changed", func(t *testing.T, dir string, src []byte) []byte {
+			return append(src, "\n// changed\n"...)
+		}},
+		{"dependency changed", func(t *testing.T, dir string, src []byte) []byte {
+			if err := os.WriteFile(filepath.Join(dir, "schemas", "money.yaml"), []byte("type: number\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			return src
+		}},
+		{"dependency removed", func(t *testing.T, dir string, src []byte) []byte {
+			if err := os.Remove(filepath.Join(dir, "schemas", "money.yaml")); err != nil {
+				t.Fatal(err)
+			}
+			return src
+		}},
+		{"version changed", func(t *testing.T, dir string, src []byte) []byte {
+			old := version.Version
+			version.Version = "v0.0.0-test"
+			t.Cleanup(func() { version.Version = old })
+			return src
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := copyFixture(t)
+			cacheDir := t.TempDir()
+			path := filepath.Join(dir, "main.go")
+			src, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := parseFile(path, src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cache := openCache(cacheDir, []string{dir})
+			cache.put(path, src, result)
+			cache.save(false)
+			if _, ok := openCache(cacheDir, []string{dir}).get(path, src); !ok {
+				t.Fatal("get missed before the change")
+			}
+
+			src = tt.change(t, dir, src)
+			if _, ok := openCache(cacheDir, []string{dir}).get(path, src); ok {
+				t.Error("get hit after the change")
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package parser
+
+import (
+	"fmt"
+	"go/token"
+)
+
+// Severity of a Diagnostic.
+const (
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Diagnostic is a problem found while parsing that does not stop the spec from being generated.
+type Diagnostic struct {
+	Pos      token.Position
+	Severity string
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	if d.Pos.IsValid() {
+		return fmt.Sprintf("%s: %s: %s", d.Pos, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+}
+
+func warningf(pos token.Position, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{Pos: pos, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)}
+}
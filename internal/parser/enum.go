@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"strings"
+
+	"github.com/muratmirgun/socketeer/internal/spec"
+)
+
+// fileEnums returns the typed constants of a file, such as
+//
+//	type Status int
+//	const (
+//		Active Status = iota + 1 // Account can log in
+//		Suspended
+//	)
+//
+// as enum values keyed by type name with and without package.
+func fileEnums(file *ast.File) map[string][]spec.EnumValue {
+	enums := map[string][]spec.EnumValue{}
+	known := map[string]constant.Value{}
+	types := map[string]string{} // type of each typed constant
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.CONST {
+			continue
+		}
+		// A spec without type and values repeats the previous ones with the next iota
+		var typ ast.Expr
+		var values []ast.Expr
+		for iota, s := range gd.Specs {
+			vs := s.(*ast.ValueSpec)
+			if vs.Type != nil || len(vs.Values) > 0 {
+				typ, values = vs.Type, vs.Values
+			}
+			doc := vs.Doc
+			if doc == nil && !gd.Lparen.IsValid() {
+				doc = gd.Doc
+			}
+			if doc == nil {
+				doc = vs.Comment
+			}
+			for i, name := range vs.Names {
+				if i >= len(values) {
+					break
+				}
+				v, ok := constValue(values[i], iota, known)
+				if !ok {
+					continue
+				}
+				known[name.Name] = v
+				typeName := enumType(typ, values[i], types)
+				if typeName == "" {
+					continue
+				}
+				types[name.Name] = typeName
+				if name.Name == "_" {
+					continue
+				}
+				ev := spec.EnumValue{Value: constInterface(v), Name: name.Name, Description: commentText(doc)}
+				enums[typeName] = append(enums[typeName], ev)
+				enums[file.Name.Name+"."+typeName] = append(enums[file.Name.Name+"."+typeName], ev)
+			}
+		}
+	}
+	return enums
+}
+
+// enumType returns the local type of a constant: its declared type, the
+// conversion of its value as in `Active = Status(1)`, or the type of a typed
+// constant in its value as in `All = Read | Write`.
+func enumType(typ, value ast.Expr, types map[string]string) string {
+	if id, ok := typ.(*ast.Ident); ok {
+		return id.Name
+	}
+	if typ != nil {
+		return ""
+	}
+	switch e := unparen(value).(type) {
+	case *ast.CallExpr:
+		if id, ok := e.Fun.(*ast.Ident); ok && len(e.Args) == 1 && basicType(id.Name) == "" {
+			return id.Name
+		}
+	case *ast.Ident:
+		return types[e.Name]
+	case *ast.UnaryExpr:
+		return enumType(nil, e.X, types)
+	case *ast.BinaryExpr:
+		if t := enumType(nil, e.X, types); t != "" {
+			return t
+		}
+		if e.Op != token.SHL && e.Op != token.SHR {
+			return enumType(nil, e.Y, types)
+		}
+	}
+	return ""
+}
+
+// constValue evaluates a constant expression made of literals, iota, earlier
+// constants of the file, operators and conversions.
+func constValue(expr ast.Expr, iota int, known map[string]constant.Value) (constant.Value, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		v := constant.MakeFromLiteral(e.Value, e.Kind, 0)
+		return v, v.Kind() != constant.Unknown
+	case *ast.Ident:
+		switch e.Name {
+		case "iota":
+			return constant.MakeInt64(int64(iota)), true
+		case "true", "false":
+			return constant.MakeBool(e.Name == "true"), true
+		}
+		v, ok := known[e.Name]
+		return v, ok
+	case *ast.ParenExpr:
+		return constValue(e.X, iota, known)
+	case *ast.UnaryExpr:
+		x, ok := constValue(e.X, iota, known)
+		if !ok {
+			return nil, false
+		}
+		switch {
+		case e.Op == token.NOT && x.Kind() != constant.Bool,
+			e.Op == token.XOR && x.Kind() != constant.Int,
+			(e.Op == token.ADD || e.Op == token.SUB) && !numeric(x):
+			return nil, false // not valid Go; go/constant would panic
+		}
+		return constant.UnaryOp(e.Op, x, 0), true
+	case *ast.BinaryExpr:
+		x, ok := constValue(e.X, iota, known)
+		if !ok {
+			return nil, false
+		}
+		y, ok := constValue(e.Y, iota, known)
+		if !ok {
+			return nil, false
+		}
+		if e.Op == token.SHL || e.Op == token.SHR {
+			x = constant.ToInt(x)
+			s, ok := constant.Uint64Val(constant.ToInt(y))
+			if !ok || x.Kind() != constant.Int {
+				return nil, false
+			}
+			return constant.Shift(x, e.Op, uint(s)), true
+		}
+		if x.Kind() != y.Kind() && !(numeric(x) && numeric(y)) {
+			return nil, false // not valid Go; go/constant would panic
+		}
+		switch e.Op {
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return constant.MakeBool(constant.Compare(x, e.Op, y)), true
+		case token.QUO:
+			if x.Kind() == constant.Int && y.Kind() == constant.Int {
+				if constant.Sign(y) == 0 {
+					return nil, false
+				}
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y), true // integer division
+			}
+		}
+		v := constant.BinaryOp(x, e.Op, y)
+		return v, v.Kind() != constant.Unknown
+	case *ast.CallExpr:
+		// conversion such as Status(1)
+		if len(e.Args) == 1 {
+			return constValue(e.Args[0], iota, known)
+		}
+	}
+	return nil, false
+}
+
+func numeric(v constant.Value) bool {
+	k := v.Kind()
+	return k == constant.Int || k == constant.Float || k == constant.Complex
+}
+
+// constInterface converts a constant to the value it has in JSON.
+func constInterface(v constant.Value) interface{} {
+	switch v.Kind() {
+	case constant.String:
+		return constant.StringVal(v)
+	case constant.Bool:
+		return constant.BoolVal(v)
+	case constant.Int:
+		if n, ok := constant.Int64Val(v); ok {
+			return n
+		}
+	}
+	f, _ := constant.Float64Val(v)
+	return f
+}
+
+// commentText returns a comment group as one line.
+func commentText(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(cg.Text()), " ")
+}
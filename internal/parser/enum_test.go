@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/muratmirgun/socketeer/internal/spec"
+)
+
+func TestFileEnums(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string][]spec.EnumValue
+	}{
+		{
+			name: "typed strings",
+			src: `
+type Color string
+
+const (
+	// The default
+	Red   Color = "red"
+	Green Color = "green" // Go
+	other       = "not typed"
+)`,
+			want: map[string][]spec.EnumValue{"Color": {
+				{Value: "red", Name: "Red", Description: "The default"},
+				{Value: "green", Name: "Green", Description: "Go"},
+			}},
+		},
+		{
+			name: "typed ints",
+			src: `
+type Code int
+
+const NotFound Code = 404 // Missing
+
+const Gone = Code(410)`,
+			want: map[string][]spec.EnumValue{"Code": {
+				{Value: int64(404), Name: "NotFound", Description: "Missing"},
+				{Value: int64(410), Name: "Gone"},
+			}},
+		},
+		{
+			name: "iota with skipped values",
+			src: `
+type Status int
+
+const (
+	_ Status = iota
+	Active
+	Suspended
+	Deleted = Suspended + 10
+)`,
+			want: map[string][]spec.EnumValue{"Status": {
+				{Value: int64(1), Name: "Active"},
+				{Value: int64(2), Name: "Suspended"},
+				{Value: int64(12), Name: "Deleted"},
+			}},
+		},
+		{
+			name: "bit flags",
+			src: `
+type Perm uint8
+
+const (
+	Read Perm = 1 << iota
+	Write
+	All = Read | Write
+)`,
+			want: map[string][]spec.EnumValue{"Perm": {
+				{Value: int64(1), Name: "Read"},
+				{Value: int64(2), Name: "Write"},
+				{Value: int64(3), Name: "All"},
+			}},
+		},
+		{
+			name: "untyped and unknown constants",
+			src: `
+const (
+	Limit   = 10
+	Timeout = time.Second * 5
+	Bad     = 1 / 0
+)`,
+			want: map[string][]spec.EnumValue{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseFile(token.NewFileSet(), "enums.go", "package model\n"+tt.src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := fileEnums(file)
+			// Every type is also keyed with its package
+			want := map[string][]spec.EnumValue{}
+			for name, values := range tt.want {
+				want[name], want["model."+name] = values, values
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("fileEnums =\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+}
+
+// TestEnumAcrossFiles declares a type and its constants in separate files.
+func TestEnumAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"status.go": "package model\n\ntype Status string\n",
+		"active.go": "package model\n\nconst (\n\tActive Status = \"active\"\n\tIdle   Status = \"idle\"\n)\n",
+		"banned.go": "package model\n\n// Banned accounts cannot log in.\nconst Banned Status = \"banned\"\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ix := NewIndex(dir)
+	if err := ix.Load(); err != nil {
+		t.Fatal(err)
+	}
+	// Files are merged in path order
+	want := []spec.EnumValue{
+		{Value: "active", Name: "Active"},
+		{Value: "idle", Name: "Idle"},
+		{Value: "banned", Name: "Banned", Description: "Banned accounts cannot log in."},
+	}
+	for _, name := range []string{"Status", "model.Status"} {
+		if got := ix.Structs()[name].enum; !reflect.DeepEqual(got, want) {
+			t.Errorf("enum of %s = %+v, want %+v", name, got, want)
+		}
+	}
+}
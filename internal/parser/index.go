@@ -0,0 +1,473 @@
+package parser
+
+import (
+	"cmp"
+	"go/ast"
+	"go/token"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/muratmirgun/socketeer/internal/spec"
+)
+
+// Index caches the parse results of every Go file under its directories, so
+// a changed file can be re-parsed without walking the whole tree again.
+type Index struct {
+	// Infer enables message inference from handler code.
+	Infer bool
+	// Include and Exclude select files by globs relative to the directories (see matchAny).
+	Include []string
+	Exclude []string
+	// Tags are the build tags files are matched against, as with go build -tags.
+	Tags []string
+	// Generated includes files marked "Code generated ... DO NOT EDIT.", which are skipped by default.
+	Generated bool
+	// Defaults and DefaultServers are used where annotations declare no API info or servers.
+	Defaults       spec.Info
+	DefaultServers []spec.Server
+	// CacheDir keeps parse results between runs, keyed by file content. Empty
+	// disables the cache, and so does Infer, which needs the function bodies.
+	CacheDir string
+
+	dirs      []string
+	roots     []string // dirs with go.work workspaces replaced by their modules
+	workspace bool     // some dir has a go.work file
+	files     map[string]*fileResult
+	failed    map[string]Diagnostic // files that could not be parsed
+	cache     *parseCache           // opened by Load when CacheDir is set
+}
+
+// NewIndex returns an empty index for one or more directories. Call Load to populate it.
+func NewIndex(dirs ...string) *Index {
+	return &Index{dirs: dirs, roots: dirs, files: map[string]*fileResult{}, failed: map[string]Diagnostic{}}
+}
+
+// Dirs returns the directories the index scans. Once loaded, a directory with
+// a go.work file is replaced by the modules the workspace uses.
+func (ix *Index) Dirs() []string {
+	return ix.roots
+}
+
+// Load walks the directories and parses every selected Go source file
+// concurrently. Files that fail to parse are reported by Diagnostics.
+func (ix *Index) Load() error {
+	ix.files = map[string]*fileResult{}
+	ix.failed = map[string]Diagnostic{}
+	roots, workspace, err := workspaceRoots(ix.dirs)
+	if err != nil {
+		return err
+	}
+	ix.roots, ix.workspace = roots, workspace
+	ix.cache = nil
+	if ix.CacheDir != "" && !ix.Infer {
+		ix.cache = openCache(ix.CacheDir, ix.roots)
+	}
+	var paths []string
+	for _, root := range ix.roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != root && ix.skipDir(path) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			paths = append(paths, filepath.Clean(path))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	results := make([]parsed, len(paths))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = ix.parse(paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	for i, path := range paths {
+		ix.store(path, results[i]) // failures are kept in ix.failed
+	}
+	if ix.cache != nil {
+		ix.cache.save(true)
+	}
+	return nil
+}
+
+// Update re-parses a single file. Paths that are not selected Go source files
+// are ignored, or dropped if they were indexed before, e.g. after a change to
+// their build constraints. A file that fails to parse keeps its previous result.
+func (ix *Index) Update(path string) error {
+	path = filepath.Clean(path)
+	err := ix.store(path, ix.parse(path))
+	if ix.cache != nil {
+		ix.cache.save(false)
+	}
+	return err
+}
+
+// parsed is the outcome of parsing one file; result is nil for files that
+// are not selected.
+type parsed struct {
+	result *fileResult
+	err    error
+}
+
+// parse reads and parses a file, or takes its result from the cache. It does
+// not modify the index, so files can be parsed concurrently.
+func (ix *Index) parse(path string) parsed {
+	if !ix.selected(path) {
+		return parsed{}
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return parsed{err: err}
+	}
+	if ix.cache != nil {
+		if result, ok := ix.cache.get(path, src); ok {
+			return parsed{result: result}
+		}
+	}
+	result, err := parseFile(path, src)
+	if err != nil {
+		return parsed{err: err}
+	}
+	if ix.cache != nil {
+		ix.cache.put(path, src, result)
+	}
+	return parsed{result: result}
+}
+
+// store records the outcome of parsing a file.
+func (ix *Index) store(path string, p parsed) error {
+	if p.err != nil {
+		ix.failed[path] = parseFailure(p.err)
+		return p.err
+	}
+	delete(ix.failed, path)
+	if p.result == nil || p.result.generated && !ix.Generated {
+		delete(ix.files, path)
+		return nil
+	}
+	ix.files[path] = p.result
+	return nil
+}
+
+// Remove drops a deleted file, or every file below a deleted directory, from the index.
+// It reports whether anything was removed.
+func (ix *Index) Remove(path string) bool {
+	path = filepath.Clean(path)
+	prefix := path + string(filepath.Separator)
+	removed := false
+	for p := range ix.files {
+		if p == path || strings.HasPrefix(p, prefix) {
+			delete(ix.files, p)
+			removed = true
+		}
+	}
+	for p := range ix.failed {
+		if p == path || strings.HasPrefix(p, prefix) {
+			delete(ix.failed, p)
+			removed = true
+		}
+	}
+	return removed
+}
+
+// paths returns the indexed file paths in walk order.
+func (ix *Index) paths() []string {
+	paths := make([]string, 0, len(ix.files))
+	for p := range ix.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Structs returns the struct index merged across all files.
+func (ix *Index) Structs() map[string]StructInfo {
+	structs := map[string]StructInfo{}
+	for _, p := range ix.paths() {
+		for name, info := range ix.files[p].structs {
+			structs[name] = info
+		}
+	}
+	// Constants may be declared in another file than their type
+	for _, p := range ix.paths() {
+		for name, values := range ix.files[p].enums {
+			if info, ok := structs[name]; ok && info.basic != "" {
+				info.enum = append(info.enum[:len(info.enum):len(info.enum)], values...)
+				structs[name] = info
+			}
+		}
+		// and so may methods; MarshalJSON wins over MarshalText
+		for name, kind := range ix.files[p].marshals {
+			if info, ok := structs[name]; ok && info.marshal != "json" {
+				info.marshal = kind
+				structs[name] = info
+			}
+		}
+		for name, schema := range ix.files[p].schemas {
+			if info, ok := structs[name]; ok {
+				info.override = schema
+				structs[name] = info
+			}
+		}
+	}
+	return structs
+}
+
+// Sockets parses every indexed @WebSocket block against the shared struct index
+// and returns the documented sockets. URLs missing from @URL are taken from route registrations.
+func (ix *Index) Sockets() []*spec.Socket {
+	sockets, _ := ix.build()
+	return sockets
+}
+
+// Diagnostics returns the problems found in the indexed files: files that
+// could not be parsed, @URLs that disagree with the registered route and
+// upgrading handlers without annotations, among others.
+func (ix *Index) Diagnostics() []Diagnostic {
+	_, diags := ix.build()
+	return diags
+}
+
+func (ix *Index) build() ([]*spec.Socket, []Diagnostic) {
+	structMap := ix.Structs()
+	routes := map[string][]route{}
+	annotated := map[string]bool{}
+	funcs := map[string]map[string]*ast.FuncDecl{} // by directory, i.e. package
+	consts := map[string]map[string]string{}
+	for _, p := range ix.paths() {
+		dir := filepath.Dir(p)
+		if funcs[dir] == nil {
+			funcs[dir], consts[dir] = map[string]*ast.FuncDecl{}, map[string]string{}
+		}
+		maps.Copy(funcs[dir], ix.files[p].funcs)
+		maps.Copy(consts[dir], ix.files[p].consts)
+		for _, r := range ix.files[p].routes {
+			routes[r.handler] = append(routes[r.handler], r)
+		}
+		for name := range ix.files[p].annotated {
+			annotated[name] = true
+		}
+	}
+
+	// @Socket fragments continue the socket declared with the same @WebSocket name
+	fragments := map[string][]socketBlock{}
+	for _, p := range ix.paths() {
+		for _, f := range ix.files[p].fragments {
+			fragments[f.ref] = append(fragments[f.ref], f)
+		}
+	}
+
+	// Routes name methods without their receiver type, so a name shared by
+	// several annotated methods cannot be linked to a route.
+	names := map[string]int{}
+	for _, p := range ix.paths() {
+		for _, block := range ix.files[p].blocks {
+			names[block.name]++
+		}
+	}
+
+	var sockets []*spec.Socket
+	var diags []Diagnostic
+	failed := slices.Sorted(maps.Keys(ix.failed))
+	for _, p := range failed {
+		diags = append(diags, ix.failed[p])
+	}
+	for _, p := range ix.paths() {
+		diags = append(diags, ix.files[p].diags...)
+	}
+	for _, p := range ix.paths() {
+		for _, block := range ix.files[p].blocks {
+			lines, errs := exampleFiles(block.lines, filepath.Dir(block.pos.Filename))
+			for _, err := range errs {
+				diags = append(diags, warningf(block.pos, "%v", err))
+			}
+			name := socketName(lines)
+			for _, f := range fragments[name] {
+				more, errs := exampleFiles(f.lines, filepath.Dir(f.pos.Filename))
+				for _, err := range errs {
+					diags = append(diags, warningf(f.pos, "%v", err))
+				}
+				lines = append(append(lines[:len(lines):len(lines)], ""), more...)
+			}
+			delete(fragments, name)
+			socket := parseSocketBlock(lines, structMap)
+			if names[block.name] == 1 {
+				diags = append(diags, linkRoute(socket, block, routes[block.name])...)
+			}
+			if ix.Infer {
+				dir := filepath.Dir(p)
+				fn := funcs[dir][block.handler]
+				if fn == nil {
+					fn = funcs[dir][block.handler+".ServeHTTP"] // annotated handler type
+				}
+				found := inferMessages(fn, funcs[dir], consts[dir])
+				diags = append(diags, mergeInferred(socket, block, found, structMap)...)
+			}
+			sockets = append(sockets, socket)
+		}
+	}
+	for _, p := range ix.paths() {
+		for _, f := range ix.files[p].fragments {
+			if _, unresolved := fragments[f.ref]; unresolved {
+				diags = append(diags, warningf(f.pos, "@Socket %s does not match any @WebSocket", f.ref))
+			}
+		}
+	}
+	for _, p := range ix.paths() {
+		for _, fn := range ix.files[p].upgraders {
+			if !annotated[fn.name] && !annotated[fn.recv] {
+				diags = append(diags, warningf(fn.pos, "%s upgrades to a WebSocket but has no @WebSocket annotations", fn.name))
+			}
+		}
+	}
+	return sockets, diags
+}
+
+// SocketSource is where a socket is documented in Go source.
+type SocketSource struct {
+	Pos    token.Position // the declaration with the @WebSocket annotation
+	Ignore []string       // lint rules disabled by //socketeer:ignore in the socket's blocks
+}
+
+// Sources returns the source of every documented socket by name.
+func (ix *Index) Sources() map[string]SocketSource {
+	sources := map[string]SocketSource{}
+	for _, p := range ix.paths() {
+		for _, block := range ix.files[p].blocks {
+			name := socketName(block.lines)
+			src := sources[name]
+			if !src.Pos.IsValid() {
+				src.Pos = block.pos
+			}
+			src.Ignore = append(src.Ignore, block.ignore...)
+			sources[name] = src
+		}
+	}
+	for _, p := range ix.paths() {
+		for _, f := range ix.files[p].fragments {
+			if src, ok := sources[f.ref]; ok {
+				src.Ignore = append(src.Ignore, f.ignore...)
+				sources[f.ref] = src
+			}
+		}
+	}
+	return sources
+}
+
+// socketName returns the name given by the block's @WebSocket annotation.
+func socketName(lines []string) string {
+	for _, line := range lines {
+		if fields := strings.Fields(line); len(fields) > 1 && fields[0] == "@WebSocket" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// linkRoute fills in a missing socket URL from the handler's route registration,
+// or warns when @URL matches none of its registrations.
+func linkRoute(socket *spec.Socket, block socketBlock, routes []route) []Diagnostic {
+	if len(routes) == 0 {
+		return nil
+	}
+	if socket.URL == "" {
+		socket.URL = routeURL(routes[0].path)
+		addPathParams(socket)
+		return nil
+	}
+	want := normalizeRoutePath(socket.URL)
+	for _, r := range routes {
+		// A relative @URL may omit a base path that belongs to the server.
+		if strings.HasSuffix(normalizeRoutePath(r.path), want) {
+			return nil
+		}
+	}
+	return []Diagnostic{warningf(block.pos, "@URL %s of %s does not match route %s registered at %s",
+		socket.URL, socket.Name, routes[0].path, routes[0].pos)}
+}
+
+// Info returns the API info annotations found in the indexed files.
+func (ix *Index) Info() spec.Info {
+	info := spec.Info{}
+	for _, p := range ix.paths() {
+		for _, l := range ix.files[p].info {
+			parseInfoLine(&info, l)
+		}
+	}
+	return info
+}
+
+// Servers returns the servers declared by @server annotations across all indexed files.
+func (ix *Index) Servers() []spec.Server {
+	var servers []spec.Server
+	for _, p := range ix.paths() {
+		for _, l := range ix.files[p].info {
+			parseServerLine(&servers, l)
+		}
+	}
+	return servers
+}
+
+// Spec assembles the full spec from the index, filling in missing API info from
+// Defaults and then built-in values.
+func (ix *Index) Spec() spec.Spec {
+	s, _ := ix.Build()
+	return s
+}
+
+// Build returns the Spec along with the Diagnostics, building the sockets
+// once for both.
+func (ix *Index) Build() (spec.Spec, []Diagnostic) {
+	sockets, diags := ix.build()
+	info := ix.Info()
+	def := ix.Defaults
+	info.Title = cmp.Or(info.Title, def.Title, "WebSocket API")
+	info.Version = cmp.Or(info.Version, def.Version, "1.0.0")
+	info.Description = cmp.Or(info.Description, def.Description, "Generated by wsdoc")
+	info.Contact.Name = cmp.Or(info.Contact.Name, def.Contact.Name)
+	info.Contact.Email = cmp.Or(info.Contact.Email, def.Contact.Email)
+	info.License.Name = cmp.Or(info.License.Name, def.License.Name)
+	info.License.URL = cmp.Or(info.License.URL, def.License.URL)
+	s := spec.Spec{
+		Info:    info,
+		Servers: ix.Servers(),
+		Sockets: []spec.Socket{},
+	}
+	if len(s.Servers) == 0 {
+		s.Servers = ix.DefaultServers
+	}
+	for _, sock := range sockets {
+		s.Sockets = append(s.Sockets, *sock)
+	}
+	return s, diags
+}
+
+// isSourceFile reports whether path is a non-test Go file.
+func isSourceFile(path string) bool {
+	return strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go")
+}
@@ -0,0 +1,191 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// benchFiles is the number of Go files in the benchmark source tree.
+const benchFiles = 200
+
+// writeBenchSources writes benchFiles files, each with a payload struct and a
+// socket handler using it, and returns their directory.
+func writeBenchSources(b *testing.B) string {
+	b.Helper()
+	dir := b.TempDir()
+	for i := 0; i < benchFiles; i++ {
+		if err := os.WriteFile(benchPath(dir, i), benchSource(i, ""), 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func benchPath(dir string, i int) string {
+	return filepath.Join(dir, fmt.Sprintf("socket%03d.go", i))
+}
+
+func benchSource(i int, comment string) []byte {
+	return []byte(fmt.Sprintf(`package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Message%[1]d is sent on socket %[1]d.%[2]s
+type Message%[1]d struct {
+	ID    int      `+"`json:\"id\"`"+`
+	Text  string   `+"`json:\"text\"`"+`
+	Tags  []string `+"`json:\"tags\"`"+`
+	Count int      `+"`json:\"count,omitempty,string\"`"+`
+}
+
+// @WebSocket Socket%[1]d
+// @URL /ws/socket%[1]d
+// @ConnectionParam token in=header type=string required=true Access token
+// @Message message
+// @Send
+// @Payload Message%[1]d
+// @Receive
+// @Payload {"ok": true}
+func Handler%[1]d(messages <-chan Message%[1]d, send func([]byte) error) error {
+	seen := map[int]bool{}
+	for m := range messages {
+		if seen[m.ID] || len(m.Text) == 0 {
+			continue
+		}
+		seen[m.ID] = true
+		for _, tag := range m.Tags {
+			if tag == "" {
+				return fmt.Errorf("message %%d: empty tag", m.ID)
+			}
+		}
+		data, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if err := send(data); err != nil {
+			return fmt.Errorf("sending message %%d: %%w", m.ID, err)
+		}
+	}
+	return nil
+}
+`, i, comment) + benchHelpers(i))
+}
+
+// benchHelpers returns unannotated code: most of a real source file, which
+// has to be parsed but leaves nothing in the cache.
+func benchHelpers(i int) string {
+	var b strings.Builder
+	for j := 0; j < 10; j++ {
+		fmt.Fprintf(&b, `
+func filter%[1]d_%[2]d(in []Message%[1]d, keep func(Message%[1]d) bool) []Message%[1]d {
+	var out []Message%[1]d
+	for _, m := range in {
+		switch {
+		case m.Count < 0:
+			continue
+		case keep != nil && !keep(m):
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+`, i, j)
+	}
+	return b.String()
+}
+
+func loadBench(b *testing.B, dir, cacheDir string) {
+	ix := NewIndex(dir)
+	ix.CacheDir = cacheDir
+	if err := ix.Load(); err != nil {
+		b.Fatal(err)
+	}
+	if len(ix.Sockets()) != benchFiles {
+		b.Fatalf("got %d sockets, want %d", len(ix.Sockets()), benchFiles)
+	}
+}
+
+// The Index.Load benchmarks show that with a cache, generation time depends
+// on the number of changed files rather than the size of the tree:
+//
+//	go test ./internal/parser -run '^$' -bench IndexLoad
+
+// BenchmarkIndexLoadCold parses every file.
+func BenchmarkIndexLoadCold(b *testing.B) {
+	dir := writeBenchSources(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		loadBench(b, dir, "")
+	}
+}
+
+// BenchmarkIndexLoadWarm reads every file from the cache.
+func BenchmarkIndexLoadWarm(b *testing.B) {
+	dir := writeBenchSources(b)
+	cacheDir := b.TempDir()
+	loadBench(b, dir, cacheDir)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		loadBench(b, dir, cacheDir)
+	}
+}
+
+// BenchmarkIndexLoadOneChanged changes one file before every load, so only
+// that file is parsed again.
+func BenchmarkIndexLoadOneChanged(b *testing.B) {
+	dir := writeBenchSources(b)
+	cacheDir := b.TempDir()
+	loadBench(b, dir, cacheDir)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		if err := os.WriteFile(benchPath(dir, i%benchFiles), benchSource(i%benchFiles, fmt.Sprintf(" Revision %d.", i)), 0o644); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		loadBench(b, dir, cacheDir)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	ix := NewIndex(copyFixture(t))
+	if err := ix.Load(); err != nil {
+		t.Fatal(err)
+	}
+	s, diags := ix.Build()
+	if want := ix.Spec(); !reflect.DeepEqual(s, want) {
+		t.Errorf("Build spec = %+v, want %+v", s, want)
+	}
+	if want := ix.Diagnostics(); !reflect.DeepEqual(diags, want) {
+		t.Errorf("Build diagnostics = %v, want %v", diags, want)
+	}
+}
+
+func TestSources(t *testing.T) {
+	ix := NewIndex("testdata/ignore")
+	if err := ix.Load(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for name, src := range ix.Sources() {
+		got = append(got, fmt.Sprintf("%s %s:%d %q", name, filepath.Base(src.Pos.Filename), src.Pos.Line, src.Ignore))
+	}
+	sort.Strings(got)
+	// Ignores of a @Socket fragment apply to the socket it continues
+	want := []string{
+		`Lobby chat.go:22 []`,
+		`Room chat.go:9 ["message-naming" "send-reply" "message-description"]`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sources = %q, want %q", got, want)
+	}
+}
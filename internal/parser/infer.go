@@ -0,0 +1,425 @@
+package parser
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"github.com/muratmirgun/socketeer/internal/spec"
+)
+
+// maxInferDepth limits how deep calls from a handler are followed.
+const maxInferDepth = 5
+
+// inferredMessage is a message type discovered in handler code.
+type inferredMessage struct {
+	typ       string
+	direction string // send | receive
+	payload   string // struct name, if known
+	literal   map[string]interface{}
+}
+
+// inference walks a handler and the package functions it calls, looking for
+// messages decoded into a struct and switched on by a discriminator field
+// (sent by the client) and values written with WriteJSON (received by the client).
+type inference struct {
+	funcs   map[string]*ast.FuncDecl
+	consts  map[string]string
+	visited map[*ast.FuncDecl]bool
+	found   []inferredMessage
+	seen    map[string]bool
+}
+
+// inferMessages returns the messages handled by fn. funcs and consts are the
+// functions and string constants of fn's package.
+func inferMessages(fn *ast.FuncDecl, funcs map[string]*ast.FuncDecl, consts map[string]string) []inferredMessage {
+	in := &inference{funcs: funcs, consts: consts, visited: map[*ast.FuncDecl]bool{}, seen: map[string]bool{}}
+	in.walk(fn, map[string]bool{}, 0)
+	return in.found
+}
+
+func (in *inference) add(m inferredMessage) {
+	key := m.direction + " " + m.typ
+	if m.typ == "" || in.seen[key] {
+		return
+	}
+	in.seen[key] = true
+	in.found = append(in.found, m)
+}
+
+// walk analyses one function. decoded holds the names of variables a message was decoded into.
+func (in *inference) walk(fn *ast.FuncDecl, decoded map[string]bool, depth int) {
+	if fn == nil || fn.Body == nil || in.visited[fn] || depth > maxInferDepth {
+		return
+	}
+	in.visited[fn] = true
+	types := varTypes(fn)
+
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if v := decodeTarget(n); v != "" {
+				decoded[v] = true
+			}
+		case *ast.SwitchStmt:
+			in.switchCases(n, decoded, types)
+		}
+		return true
+	})
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if m, ok := in.written(call); ok {
+			in.add(m)
+			return true
+		}
+		// Follow calls into the package, passing on which arguments hold decoded messages.
+		callee := in.callee(call)
+		if callee == nil {
+			return true
+		}
+		next := map[string]bool{}
+		params := paramNames(callee)
+		for i, arg := range call.Args {
+			if id, ok := unparen(arg).(*ast.Ident); ok && decoded[id.Name] && i < len(params) {
+				next[params[i]] = true
+			}
+		}
+		in.walk(callee, next, depth+1)
+		return true
+	})
+}
+
+// switchCases records the cases of `switch msg.Type` as sent messages, with the
+// payload struct decoded inside each case.
+func (in *inference) switchCases(sw *ast.SwitchStmt, decoded map[string]bool, types map[string]string) {
+	sel, ok := unparen(sw.Tag).(*ast.SelectorExpr)
+	if !ok {
+		return
+	}
+	id, ok := sel.X.(*ast.Ident)
+	if !ok || !decoded[id.Name] {
+		return
+	}
+	for _, stmt := range sw.Body.List {
+		cc, ok := stmt.(*ast.CaseClause)
+		if !ok {
+			continue
+		}
+		payload := ""
+		for _, s := range cc.Body {
+			ast.Inspect(s, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok && payload == "" {
+					if v := decodeTarget(call); v != "" {
+						payload = types[v]
+					}
+				}
+				return payload == ""
+			})
+		}
+		for _, expr := range cc.List {
+			if v, ok := in.stringValue(expr); ok {
+				in.add(inferredMessage{typ: v, direction: "send", payload: payload})
+			}
+		}
+	}
+}
+
+// written recognises conn.WriteJSON(v) and wsjson.Write(ctx, conn, v) and
+// returns the message written when its type can be determined.
+func (in *inference) written(call *ast.CallExpr) (inferredMessage, bool) {
+	recv, method, ok := selector(call.Fun)
+	if !ok || len(call.Args) == 0 {
+		return inferredMessage{}, false
+	}
+	if !(method == "WriteJSON" || (recv == "wsjson" && method == "Write")) {
+		return inferredMessage{}, false
+	}
+	arg := unparen(call.Args[len(call.Args)-1])
+	if u, ok := arg.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		arg = u.X
+	}
+	lit, ok := arg.(*ast.CompositeLit)
+	if !ok {
+		return inferredMessage{}, false
+	}
+	m := inferredMessage{direction: "receive"}
+	// gin.H, echo.Map and bson.M are maps; any other named type is the payload struct
+	if name := typeName(lit.Type); name != "" && name != "H" && name != "M" && name != "Map" {
+		m.payload = name
+	}
+	literal := map[string]interface{}{}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key := ""
+		if id, ok := kv.Key.(*ast.Ident); ok {
+			key = id.Name
+		} else if s, ok := stringLit(kv.Key); ok {
+			key = s
+		}
+		if v, ok := in.stringValue(kv.Value); ok {
+			literal[key] = v
+			if discriminators[key] && m.typ == "" {
+				m.typ = v
+			}
+		} else {
+			literal[key] = literalValue(kv.Value)
+		}
+	}
+	if m.payload == "" {
+		m.literal = literal
+	}
+	return m, m.typ != ""
+}
+
+// literalValue evaluates bool and number literals; anything else is documented as a string.
+func literalValue(expr ast.Expr) interface{} {
+	switch e := unparen(expr).(type) {
+	case *ast.Ident:
+		if e.Name == "true" || e.Name == "false" {
+			return e.Name == "true"
+		}
+	case *ast.BasicLit:
+		if e.Kind == token.INT || e.Kind == token.FLOAT {
+			if f, err := strconv.ParseFloat(e.Value, 64); err == nil {
+				return f
+			}
+		}
+	}
+	return "string"
+}
+
+// discriminators are the field and key names that carry a message's type.
+var discriminators = map[string]bool{
+	"Type": true, "type": true, "Kind": true, "kind": true,
+	"Event": true, "event": true, "Action": true, "action": true, "Op": true, "op": true,
+}
+
+// callee returns the package function or method called, by name.
+func (in *inference) callee(call *ast.CallExpr) *ast.FuncDecl {
+	switch f := call.Fun.(type) {
+	case *ast.Ident:
+		return in.funcs[f.Name]
+	case *ast.SelectorExpr:
+		return in.funcs[f.Sel.Name]
+	}
+	return nil
+}
+
+// stringValue evaluates a string literal or a package string constant.
+func (in *inference) stringValue(expr ast.Expr) (string, bool) {
+	expr = unparen(expr)
+	if s, ok := stringLit(expr); ok {
+		return s, true
+	}
+	if id, ok := expr.(*ast.Ident); ok {
+		v, ok := in.consts[id.Name]
+		return v, ok
+	}
+	if sel, ok := expr.(*ast.SelectorExpr); ok {
+		v, ok := in.consts[sel.Sel.Name]
+		return v, ok
+	}
+	return "", false
+}
+
+// decodeTarget returns the variable decoded into by json.Unmarshal(data, &v),
+// conn.ReadJSON(&v), wsjson.Read(ctx, conn, &v) or decoder.Decode(&v).
+func decodeTarget(call *ast.CallExpr) string {
+	_, method, ok := selector(call.Fun)
+	if !ok || len(call.Args) == 0 {
+		return ""
+	}
+	switch method {
+	case "Unmarshal", "ReadJSON", "Decode", "Read":
+	default:
+		return ""
+	}
+	u, ok := unparen(call.Args[len(call.Args)-1]).(*ast.UnaryExpr)
+	if !ok || u.Op != token.AND {
+		if id, ok := unparen(call.Args[len(call.Args)-1]).(*ast.Ident); ok && method != "Read" {
+			return id.Name // already a pointer
+		}
+		return ""
+	}
+	if id, ok := u.X.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// varTypes maps the variables and parameters of fn to their declared struct type names.
+func varTypes(fn *ast.FuncDecl) map[string]string {
+	types := map[string]string{}
+	if fn.Type.Params != nil {
+		for _, f := range fn.Type.Params.List {
+			for _, n := range f.Names {
+				types[n.Name] = typeName(f.Type)
+			}
+		}
+	}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if n.Type != nil {
+					types[name.Name] = typeName(n.Type)
+				} else if i < len(n.Values) {
+					types[name.Name] = exprType(n.Values[i])
+				}
+			}
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE || len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, lhs := range n.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok {
+					if t := exprType(n.Rhs[i]); t != "" {
+						types[id.Name] = t
+					}
+				}
+			}
+		}
+		return true
+	})
+	return types
+}
+
+// exprType returns the struct type of T{}, &T{} and new(T).
+func exprType(expr ast.Expr) string {
+	switch e := unparen(expr).(type) {
+	case *ast.CompositeLit:
+		return typeName(e.Type)
+	case *ast.UnaryExpr:
+		return exprType(e.X)
+	case *ast.CallExpr:
+		if id, ok := e.Fun.(*ast.Ident); ok && id.Name == "new" && len(e.Args) == 1 {
+			return typeName(e.Args[0])
+		}
+	}
+	return ""
+}
+
+// typeName returns T for T, *T and pkg.T.
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return typeName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
+
+func paramNames(fn *ast.FuncDecl) []string {
+	var names []string
+	if fn.Type.Params == nil {
+		return names
+	}
+	for _, f := range fn.Type.Params.List {
+		if len(f.Names) == 0 {
+			names = append(names, "_")
+		}
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+	}
+	return names
+}
+
+func unparen(expr ast.Expr) ast.Expr {
+	for {
+		p, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = p.X
+	}
+}
+
+// fileConsts returns the string constants declared at package level in a file.
+func fileConsts(file *ast.File) map[string]string {
+	consts := map[string]string{}
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.CONST {
+			continue
+		}
+		for _, s := range gd.Specs {
+			vs, ok := s.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			for i, name := range vs.Names {
+				if i >= len(vs.Values) {
+					continue
+				}
+				if lit, ok := vs.Values[i].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					if v, err := strconv.Unquote(lit.Value); err == nil {
+						consts[name.Name] = v
+					}
+				}
+			}
+		}
+	}
+	return consts
+}
+
+// fileFuncs returns the functions and methods declared in a file, keyed by
+// name and, for methods, also by receiver-qualified name.
+func fileFuncs(file *ast.File) map[string]*ast.FuncDecl {
+	funcs := map[string]*ast.FuncDecl{}
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			funcs[fn.Name.Name] = fn
+			funcs[funcKey(fn)] = fn
+		}
+	}
+	return funcs
+}
+
+// mergeInferred adds inferred messages missing from the socket's annotations,
+// marked as inferred, and warns about each one.
+func mergeInferred(socket *spec.Socket, block socketBlock, found []inferredMessage, structMap map[string]StructInfo) []Diagnostic {
+	var diags []Diagnostic
+	for _, m := range found {
+		var group *spec.GroupedMessage
+		for i := range socket.GroupedMessages {
+			if socket.GroupedMessages[i].Type == m.typ {
+				group = &socket.GroupedMessages[i]
+			}
+		}
+		if group == nil {
+			socket.GroupedMessages = append(socket.GroupedMessages, spec.GroupedMessage{Type: m.typ})
+			group = &socket.GroupedMessages[len(socket.GroupedMessages)-1]
+		}
+		if (m.direction == "send" && group.Send != nil) || (m.direction == "receive" && group.Receive != nil) {
+			continue
+		}
+		msg := &spec.Message{Type: m.typ, Direction: m.direction, Inferred: true}
+		if m.payload != "" {
+			msg.Payload, _ = structPayload(structMap, m.payload)
+			msg.Schema = structSchema(structMap, m.payload)
+		} else if m.literal != nil {
+			if b, err := json.Marshal(m.literal); err == nil {
+				msg.Payload = string(b)
+			}
+		}
+		if m.direction == "send" {
+			group.Send = msg
+		} else {
+			group.Receive = msg
+		}
+		socket.Messages = append(socket.Messages, *msg)
+		diags = append(diags, warningf(block.pos, "%s handles undocumented %s message %q", block.handler, m.direction, m.typ))
+	}
+	return diags
+}
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestInferMessages(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "testdata/infer/handlers.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funcs, consts := fileFuncs(file), fileConsts(file)
+	// Each message is "<direction> <type> <payload struct or literal>"
+	tests := map[string][]string{
+		"readWriteJSON": {
+			"send join JoinRequest",
+			"send leave ",
+			"send quit ",
+			"receive welcome Welcome",
+			"receive error map[code:4 detail:string fatal:true type:error]",
+		},
+		"unmarshalTyped": {
+			"send ping ",
+			"receive pong Pong",
+		},
+		"wsjsonHandler": {
+			"send subscribe Subscription",
+			"receive ack Ack",
+		},
+		"opaque": nil,
+	}
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, m := range inferMessages(funcs[name], funcs, consts) {
+				payload := m.payload
+				if m.literal != nil {
+					payload = fmt.Sprint(m.literal)
+				}
+				got = append(got, fmt.Sprintf("%s %s %s", m.direction, m.typ, payload))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("inferMessages(%s) = %q, want %q", name, got, want)
+			}
+		})
+	}
+}
+
+func TestIndexInfer(t *testing.T) {
+	dir := t.TempDir()
+	const src = `package chat
+
+type Join struct {
+	Room string ` + "`json:\"room\"`" + `
+}
+
+// @WebSocket Chat
+// @URL /ws/chat
+// @Message join
+// @Send
+// @Payload Join
+func serve(conn *websocket.Conn) {
+	var msg struct{ Type string }
+	conn.ReadJSON(&msg)
+	switch msg.Type {
+	case "join", "leave":
+	}
+	conn.WriteJSON(map[string]interface{}{"type": "joined"})
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "chat.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ix := NewIndex(dir)
+	ix.Infer = true
+	if err := ix.Load(); err != nil {
+		t.Fatal(err)
+	}
+	s, diags := ix.Build()
+	var got []string
+	for _, m := range s.Sockets[0].Messages {
+		got = append(got, fmt.Sprintf("%s %s inferred=%v", m.Direction, m.Type, m.Inferred))
+	}
+	// The documented join is kept; leave is added. The map literal has no
+	// named type, but its type key still names the message
+	want := []string{"send join inferred=false", "send leave inferred=true", "receive joined inferred=true"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+	var warnings []string
+	for _, d := range diags {
+		warnings = append(warnings, d.Message)
+	}
+	wantWarnings := []string{`serve handles undocumented send message "leave"`, `serve handles undocumented receive message "joined"`}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("diagnostics = %q, want %q", warnings, wantWarnings)
+	}
+}
@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/muratmirgun/socketeer/internal/spec"
+	"gopkg.in/yaml.v3"
+)
+
+// schemaDirective overrides the schema of a type:
+//
+//	//socketeer:schema {"type": "string", "format": "decimal"}
+//	//socketeer:schema file:schemas/money.json
+const schemaDirective = "//socketeer:schema"
+
+// wellKnownTypes are types from other packages whose JSON form differs from their Go shape.
+var wellKnownTypes = map[string]spec.Schema{
+	"time.Time":       {Type: "string", Format: "date-time"},
+	"time.Duration":   {Type: "integer", Description: "nanoseconds"},
+	"json.RawMessage": {},
+	"json.Number":     {Type: "number"},
+	"uuid.UUID":       {Type: "string", Format: "uuid"},
+	"url.URL":         {Type: "string", Format: "uri"},
+	"big.Int":         {Type: "integer"},
+	"big.Float":       {Type: "number"},
+	"net.IP":          {Type: "string"},
+	"sql.NullString":  {Type: "string"},
+	"sql.NullInt64":   {Type: "integer"},
+	"sql.NullBool":    {Type: "boolean"},
+	"sql.NullFloat64": {Type: "number"},
+	"sql.NullTime":    {Type: "string", Format: "date-time"},
+}
+
+// hasOption reports whether a comma-separated tag option list contains name.
+func hasOption(opts, name string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fileMarshalers returns the types of a file with a MarshalJSON ("json") or
+// MarshalText ("text") method, keyed by type name with and without package.
+func fileMarshalers(file *ast.File) map[string]string {
+	marshalers := map[string]string{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || recvType(fn) == "" {
+			continue
+		}
+		recv := recvType(fn)
+		var kind string
+		switch fn.Name.Name {
+		case "MarshalJSON":
+			kind = "json"
+		case "MarshalText":
+			kind = "text"
+		default:
+			continue
+		}
+		// MarshalJSON takes precedence in encoding/json
+		for _, key := range []string{recv, file.Name.Name + "." + recv} {
+			if marshalers[key] != "json" {
+				marshalers[key] = kind
+			}
+		}
+	}
+	return marshalers
+}
+
+// fileSchemas returns the schemas given by //socketeer:schema directives on
+// the types of a file and the schema files they read. File references are
+// relative to dir.
+func fileSchemas(fset *token.FileSet, file *ast.File, dir string) (map[string]*spec.Schema, []string, []Diagnostic) {
+	schemas := map[string]*spec.Schema{}
+	var deps []string
+	var diags []Diagnostic
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, s := range gd.Specs {
+			ts := s.(*ast.TypeSpec)
+			doc := ts.Doc
+			if doc == nil && !gd.Lparen.IsValid() {
+				doc = gd.Doc
+			}
+			if doc == nil {
+				continue
+			}
+			for _, c := range doc.List {
+				value, ok := strings.CutPrefix(c.Text, schemaDirective)
+				if !ok {
+					continue
+				}
+				value = strings.TrimSpace(value)
+				if ref, ok := strings.CutPrefix(value, "file:"); ok {
+					deps = append(deps, filepath.Join(dir, ref))
+				}
+				schema, err := parseSchemaDirective(value, dir)
+				if err != nil {
+					diags = append(diags, warningf(fset.Position(c.Pos()), "%s of %s: %v", schemaDirective[2:], ts.Name.Name, err))
+					continue
+				}
+				schemas[ts.Name.Name] = schema
+				schemas[file.Name.Name+"."+ts.Name.Name] = schema
+			}
+		}
+	}
+	return schemas, deps, diags
+}
+
+// parseSchemaDirective decodes an inline JSON Schema or reads it from a
+// `file:` reference. YAML files are accepted too.
+func parseSchemaDirective(value, dir string) (*spec.Schema, error) {
+	data := []byte(value)
+	if ref, ok := strings.CutPrefix(value, "file:"); ok {
+		var err error
+		if data, err = os.ReadFile(filepath.Join(dir, ref)); err != nil {
+			return nil, err
+		}
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, fmt.Errorf("missing schema")
+	}
+	var schema spec.Schema
+	if err := yaml.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
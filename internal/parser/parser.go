@@ -1,408 +1,589 @@
 package parser
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
-	"io/fs"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/muratmirgun/socketeer/internal/spec"
-	"gopkg.in/yaml.v3"
 )
 
-// Parse scans Go source files for WebSocket annotations and returns a list of Socket specs.
-func Parse(dir string) ([]*spec.Socket, error) {
-	var sockets []*spec.Socket
+// fileResult holds everything extracted from a single Go file.
+type fileResult struct {
+	blocks    []socketBlock
+	fragments []socketBlock // @Socket blocks continuing a socket declared elsewhere
+	structs   map[string]StructInfo
+	enums     map[string][]spec.EnumValue // typed constants by type name
+	marshals  map[string]string           // types with a MarshalJSON or MarshalText method
+	schemas   map[string]*spec.Schema     // //socketeer:schema overrides by type name
+	deps      []string                    // other files read, e.g. schema files
+	diags     []Diagnostic
+	generated bool     // marked "Code generated ... DO NOT EDIT."
+	info      []string // API info annotation lines in the order they apply
+	routes    []route
+	upgraders []handlerFunc
+	annotated map[string]bool // functions with any annotation block
+	funcs     map[string]*ast.FuncDecl
+	consts    map[string]string
+}
+
+// socketBlock is a merged annotation block and the declaration it documents.
+type socketBlock struct {
+	handler string // receiver-qualified declaration name
+	name    string // name route registrations refer to
+	pos     token.Position
+	lines   []string
+	ref     string   // socket a @Socket fragment adds to
+	ignore  []string // lint rules disabled by //socketeer:ignore
+}
+
+// parseFile extracts WebSocket annotation blocks, struct definitions and API info lines from one Go file.
+func parseFile(path string, src []byte) (*fileResult, error) {
 	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	result := &fileResult{
+		structs:   fileStructs(file),
+		enums:     fileEnums(file),
+		info:      infoLines(strings.Split(string(src), "\n")),
+		routes:    fileRoutes(fset, file),
+		upgraders: upgradingFuncs(fset, file),
+		annotated: map[string]bool{},
+		funcs:     fileFuncs(file),
+		consts:    fileConsts(file),
+		marshals:  fileMarshalers(file),
+		generated: ast.IsGenerated(file),
+	}
+	result.schemas, result.deps, result.diags = fileSchemas(fset, file, filepath.Dir(path))
 
-	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
+	// Attach each comment group to its declaration, keeping declarations in source order
+	nodes := fileNodes(file)
+	var order []node
+	nodeAnnots := map[string][][]string{}
+	nodeIgnores := map[string][]string{}
+	for _, cg := range file.Comments {
+		if len(cg.List) == 0 {
+			continue
 		}
-		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
-			return nil
+		block := extractAnnotationBlock(cg.List)
+		ignore := ignoreDirectives(cg.List)
+		if len(block) == 0 && len(ignore) == 0 {
+			continue
 		}
-		println("Visiting:", path)
-
-		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
-		if err != nil {
-			return err
+		n, ok := attach(nodes, cg)
+		if !ok {
+			continue
 		}
-
-		// Build a list of all function positions
-		funcs := []*ast.FuncDecl{}
-		for _, decl := range file.Decls {
-			if fn, ok := decl.(*ast.FuncDecl); ok {
-				funcs = append(funcs, fn)
-			}
+		nodeIgnores[n.key] = append(nodeIgnores[n.key], ignore...)
+		if len(block) == 0 {
+			continue
 		}
-
-		// Map: function name -> all annotation blocks
-		funcAnnots := map[string][][]string{}
-
-		// For each comment group, find the first function that follows it
-		for _, cg := range file.Comments {
-			if len(cg.List) == 0 {
-				continue
-			}
-			block := extractAnnotationBlock(cg.List)
-			if len(block) == 0 {
-				continue
-			}
-			cgEnd := cg.End()
-			for _, fn := range funcs {
-				if fn.Pos() > cgEnd {
-					funcAnnots[fn.Name.Name] = append(funcAnnots[fn.Name.Name], block)
-					break
-				}
-			}
+		if _, seen := nodeAnnots[n.key]; !seen {
+			order = append(order, n)
 		}
+		nodeAnnots[n.key] = append(nodeAnnots[n.key], block)
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i].pos < order[j].pos })
 
-		// For each function, merge all annotation blocks and parse as a single socket
-		for fnName, blocks := range funcAnnots {
-			var merged []string
-			for _, b := range blocks {
-				merged = append(merged, b...)
-			}
-			println("Function:", fnName)
-			println("Merged annotation block:", strings.Join(merged, " | "))
-			if isWebSocketBlock(merged) {
-				socket := parseSocketBlock(merged)
-				sockets = append(sockets, socket)
+	// For each declaration, merge all annotation blocks into a single socket block
+	for _, n := range order {
+		var merged []string
+		for _, b := range nodeAnnots[n.key] {
+			if len(merged) > 0 {
+				merged = append(merged, "") // ends the previous group's continuation lines
 			}
+			merged = append(merged, b...)
+		}
+		result.annotated[n.key] = true
+		block := socketBlock{handler: n.key, name: n.name, pos: fset.Position(n.pos), lines: merged, ignore: nodeIgnores[n.key]}
+		if isWebSocketBlock(merged) {
+			result.blocks = append(result.blocks, block)
+		} else if block.ref = socketRef(merged); block.ref != "" {
+			result.fragments = append(result.fragments, block)
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
 	}
-	return sockets, nil
+	return result, nil
 }
 
 // extractAnnotationBlock extracts all consecutive annotation lines from a comment group, including multi-blocks.
 func extractAnnotationBlock(comments []*ast.Comment) []string {
 	var block []string
 	for _, c := range comments {
-		line := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
-		if strings.HasPrefix(line, "@") || (len(block) > 0 && (strings.HasPrefix(line, "{") || strings.HasPrefix(line, "}"))) {
+		if !strings.HasPrefix(c.Text, "//") {
+			continue
+		}
+		if strings.HasPrefix(c.Text, "//socketeer:") {
+			continue // directive, see ignoreDirectives and fileSchemas
+		}
+		line := strings.TrimPrefix(c.Text, "//")
+		if len(block) > 0 || isAnnotationLine(strings.TrimSpace(line)) {
 			block = append(block, line)
 		}
 	}
 	return block
 }
 
+// ignoreDirective disables lint rules for the socket whose annotations it is
+// written with:
+//
+//	//socketeer:ignore message-naming send-reply
+const ignoreDirective = "//socketeer:ignore"
+
+// ignoreDirectives returns the rule IDs of the ignore directives in a comment group.
+func ignoreDirectives(comments []*ast.Comment) []string {
+	var ids []string
+	for _, c := range comments {
+		if rest, ok := strings.CutPrefix(c.Text, ignoreDirective); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			ids = append(ids, strings.FieldsFunc(rest, func(r rune) bool { return r == ' ' || r == '\t' || r == ',' })...)
+		}
+	}
+	return ids
+}
+
 // isWebSocketBlock checks if the annotation block starts with @WebSocket.
 func isWebSocketBlock(block []string) bool {
 	for _, line := range block {
-		if strings.HasPrefix(line, "@WebSocket") {
+		if strings.HasPrefix(strings.TrimSpace(line), "@WebSocket") {
 			return true
 		}
 	}
 	return false
 }
 
+// splitList splits a comma-separated list, trimming spaces around each item.
+func splitList(s string) []string {
+	items := strings.Split(s, ",")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+	}
+	return items
+}
+
+// socketRef returns the socket named by a `@Socket <name>` annotation, if any.
+func socketRef(block []string) string {
+	for _, line := range block {
+		if fields := strings.Fields(line); len(fields) > 1 && fields[0] == "@Socket" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
 // StructInfo holds struct field info for JSON example generation
 type StructInfo struct {
 	Fields map[string]interface{}
+	Order  []string // JSON field names in declaration order
+	fields []structField
+	basic  string           // underlying type of a named non-struct type, e.g. int for `type Status int`
+	enum   []spec.EnumValue // typed constants of a named non-struct type
+
+	underlying ast.Expr     // type of other named non-struct types, e.g. []string
+	marshal    string       // "json" or "text" if the type has a MarshalJSON or MarshalText method
+	override   *spec.Schema // from a //socketeer:schema directive
 }
 
-// CollectStructs walks all Go files under dir and returns a map of struct name (with/without package) to StructInfo
-func CollectStructs(dir string) map[string]StructInfo {
-	structs := map[string]StructInfo{}
-	fset := token.NewFileSet()
-	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
-			return nil
+// JSON encodes the example fields as a JSON object, keeping declaration order.
+func (s StructInfo) JSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, name := range s.Order {
+		field, ok := s.Fields[name]
+		if !ok {
+			continue
 		}
-		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		k, err := json.Marshal(name)
 		if err != nil {
-			return nil
+			return nil, err
 		}
-		pkg := file.Name.Name
-		for _, decl := range file.Decls {
-			gd, ok := decl.(*ast.GenDecl)
-			if !ok || gd.Tok != token.TYPE {
+		v, err := json.Marshal(field)
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// fileStructs returns the structs declared in a parsed file, keyed by name with and without package.
+func fileStructs(file *ast.File) map[string]StructInfo {
+	structs := map[string]StructInfo{}
+	pkg := file.Name.Name
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, specNode := range gd.Specs {
+			ts, ok := specNode.(*ast.TypeSpec)
+			if !ok {
 				continue
 			}
-			for _, specNode := range gd.Specs {
-				ts, ok := specNode.(*ast.TypeSpec)
-				st, ok2 := ts.Type.(*ast.StructType)
-				if !ok || !ok2 {
-					continue
+			if id, isIdent := ts.Type.(*ast.Ident); isIdent && basicType(id.Name) != "" && !ts.Assign.IsValid() {
+				// named basic type, possibly an enum of typed constants
+				structs[ts.Name.Name] = StructInfo{basic: id.Name}
+				structs[pkg+"."+ts.Name.Name] = StructInfo{basic: id.Name}
+				continue
+			}
+			st, ok2 := ts.Type.(*ast.StructType)
+			if !ok2 {
+				// other named types, e.g. `type Tags []string`, are described by their underlying type
+				structs[ts.Name.Name] = StructInfo{underlying: ts.Type}
+				structs[pkg+"."+ts.Name.Name] = StructInfo{underlying: ts.Type}
+				continue
+			}
+			fields := map[string]interface{}{}
+			var order []string
+			var typed []structField
+			for _, f := range st.Fields.List {
+				name := ""
+				if len(f.Names) > 0 {
+					name = f.Names[0].Name
 				}
-				fields := map[string]interface{}{}
-				for _, f := range st.Fields.List {
-					name := ""
-					if len(f.Names) > 0 {
-						name = f.Names[0].Name
-					}
-					jsonName := name
-					if f.Tag != nil {
-						tag := reflect.StructTag(strings.Trim(f.Tag.Value, "`"))
-						if j, ok := tag.Lookup("json"); ok && j != "-" {
-							jsonName = strings.Split(j, ",")[0]
-						}
-					}
-					if jsonName == "" || jsonName == "-" {
+				jsonName := name
+				var opts string
+				if f.Tag != nil {
+					tag := reflect.StructTag(strings.Trim(f.Tag.Value, "`"))
+					j := tag.Get("json")
+					if j == "-" {
 						continue
 					}
-					// Try to extract Example comment
-					example := ""
-					if f.Doc != nil {
-						for _, c := range f.Doc.List {
-							line := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
-							if strings.HasPrefix(line, "Example:") {
-								ex := strings.TrimSpace(strings.TrimPrefix(line, "Example:"))
-								if len(ex) > 0 {
-									example = ex
-								}
+					var tagName string
+					tagName, opts, _ = strings.Cut(j, ",")
+					if tagName != "" {
+						jsonName = tagName
+					}
+				}
+				// Embedded structs without a JSON name, and fields marked ,inline, are flattened into the parent
+				if (len(f.Names) == 0 && jsonName == "") || hasOption(opts, "inline") {
+					typed = append(typed, structField{typ: f.Type, embedded: true})
+					continue
+				}
+				if jsonName == "" {
+					continue
+				}
+				if _, dup := fields[jsonName]; !dup {
+					order = append(order, jsonName)
+					typed = append(typed, newStructField(jsonName, f))
+				}
+				// Try to extract Example comment
+				example := ""
+				if f.Doc != nil {
+					for _, c := range f.Doc.List {
+						line := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+						if strings.HasPrefix(line, "Example:") {
+							ex := strings.TrimSpace(strings.TrimPrefix(line, "Example:"))
+							if len(ex) > 0 {
+								example = ex
 							}
 						}
 					}
-					if example == "" && f.Comment != nil {
-						for _, c := range f.Comment.List {
-							line := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
-							if strings.HasPrefix(line, "Example:") {
-								ex := strings.TrimSpace(strings.TrimPrefix(line, "Example:"))
-								if len(ex) > 0 {
-									example = ex
-								}
+				}
+				if example == "" && f.Comment != nil {
+					for _, c := range f.Comment.List {
+						line := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+						if strings.HasPrefix(line, "Example:") {
+							ex := strings.TrimSpace(strings.TrimPrefix(line, "Example:"))
+							if len(ex) > 0 {
+								example = ex
 							}
 						}
 					}
-					if example != "" {
-						// Remove leading and trailing double quotes if present
-						if strings.HasPrefix(example, "\"") && strings.HasSuffix(example, "\"") && len(example) > 1 {
-							example = strings.TrimPrefix(example, "\"")
-							example = strings.TrimSuffix(example, "\"")
-						}
-						// Try to parse as int, float, or JSON, fallback to string
-						var v interface{} = example
-						if i, err := strconv.ParseInt(example, 10, 64); err == nil {
-							v = i
-						} else if f, err := strconv.ParseFloat(example, 64); err == nil {
-							v = f
-						} else if (strings.HasPrefix(example, "{") && strings.HasSuffix(example, "}")) || (strings.HasPrefix(example, "[") && strings.HasSuffix(example, "]")) {
-							var j interface{}
-							if err := json.Unmarshal([]byte(example), &j); err == nil {
-								v = j
-							}
+				}
+				if example != "" {
+					// Remove leading and trailing double quotes if present
+					if strings.HasPrefix(example, "\"") && strings.HasSuffix(example, "\"") && len(example) > 1 {
+						example = strings.TrimPrefix(example, "\"")
+						example = strings.TrimSuffix(example, "\"")
+					}
+					// Try to parse as int, float, or JSON, fallback to string
+					var v interface{} = example
+					if i, err := strconv.ParseInt(example, 10, 64); err == nil {
+						v = i
+					} else if f, err := strconv.ParseFloat(example, 64); err == nil {
+						v = f
+					} else if (strings.HasPrefix(example, "{") && strings.HasSuffix(example, "}")) || (strings.HasPrefix(example, "[") && strings.HasSuffix(example, "]")) {
+						var j interface{}
+						if err := json.Unmarshal([]byte(example), &j); err == nil {
+							v = j
 						}
-						fields[jsonName] = v
-					} else {
-						// Fallback: old logic
-						switch ft := f.Type.(type) {
-						case *ast.Ident:
-							t := ft.Name
-							if t == "string" {
-								fields[jsonName] = "string"
-							} else if t == "int" || t == "int64" || t == "int32" {
-								fields[jsonName] = 0
-							} else if t == "bool" {
-								fields[jsonName] = false
-							} else {
-								fields[jsonName] = t
-							}
-						case *ast.ArrayType:
-							fields[jsonName] = []interface{}{}
-						case *ast.MapType:
-							fields[jsonName] = map[string]interface{}{}
+					}
+					fields[jsonName] = v
+				} else {
+					// Fallback: old logic
+					switch ft := f.Type.(type) {
+					case *ast.Ident:
+						t := ft.Name
+						if t == "string" {
+							fields[jsonName] = "string"
+						} else if t == "int" || t == "int64" || t == "int32" {
+							fields[jsonName] = 0
+						} else if t == "bool" {
+							fields[jsonName] = false
+						} else {
+							fields[jsonName] = t
 						}
+					case *ast.ArrayType:
+						fields[jsonName] = []interface{}{}
+					case *ast.MapType:
+						fields[jsonName] = map[string]interface{}{}
 					}
 				}
-				structs[ts.Name.Name] = StructInfo{Fields: fields}
-				structs[pkg+"."+ts.Name.Name] = StructInfo{Fields: fields}
 			}
+			info := StructInfo{Fields: fields, Order: order, fields: typed}
+			structs[ts.Name.Name] = info
+			structs[pkg+"."+ts.Name.Name] = info
 		}
-		return nil
-	})
+	}
 	return structs
 }
 
 // parseSocketBlock parses a block of annotations into a Socket struct (supports grouped @Send/@Receive).
-func parseSocketBlock(block []string) *spec.Socket {
+func parseSocketBlock(block []string, structMap map[string]StructInfo) *spec.Socket {
 	socket := &spec.Socket{}
-	structMap := CollectStructs("./")
 	messageGroups := make(map[string]*spec.GroupedMessage)
+	var messageOrder []string
 
 	var currentMsg *spec.Message
 	var currentType string
 	var currentDirection string
+	var examples *[]spec.Example // where @Example adds to
 
-	for i := 0; i < len(block); i++ {
-		line := block[i]
-		fields := strings.Fields(line)
-		if len(fields) == 0 {
-			continue
+	// flush adds the current message to its group
+	flush := func() {
+		if currentMsg == nil || currentType == "" {
+			return
+		}
+		group := messageGroups[currentType]
+		switch currentDirection {
+		case "send":
+			group.Send = currentMsg
+		case "receive":
+			group.Receive = currentMsg
+		default:
+			return
+		}
+		if group.Description == "" {
+			group.Description = currentMsg.Description
 		}
-		switch fields[0] {
+	}
+
+	for _, a := range parseAnnotations(block) {
+		switch a.Name {
 		case "@WebSocket":
-			if len(fields) > 1 {
-				socket.Name = fields[1]
+			if len(a.args) > 0 {
+				socket.Name = a.args[0]
 			}
 		case "@Group":
-			if len(fields) > 1 {
-				socket.Group = strings.Join(fields[1:], " ")
+			if len(a.args) > 0 {
+				socket.Group = strings.Join(a.args, " ")
 			}
 		case "@URL":
-			if len(fields) > 1 {
-				socket.URL = fields[1]
+			if len(a.args) > 0 {
+				socket.URL = a.args[0]
 			}
+		case "@Servers":
+			socket.Servers = append(socket.Servers, a.args...)
 		case "@Description":
-			desc := strings.TrimPrefix(line, "@Description")
-			desc = strings.TrimSpace(desc)
+			desc := a.text()
 			if currentMsg != nil {
 				currentMsg.Description = desc
 			} else {
 				socket.Description = desc
 			}
 		case "@Tags":
-			if currentMsg != nil && len(fields) > 1 {
-				tags := strings.Split(strings.Join(fields[1:], " "), ",")
-				for i, t := range tags {
-					tags[i] = strings.TrimSpace(t)
-				}
-				currentMsg.Tags = tags
-			} else if currentMsg == nil && len(fields) > 1 {
-				tags := strings.Split(strings.Join(fields[1:], " "), ",")
-				for i, t := range tags {
-					tags[i] = strings.TrimSpace(t)
+			if len(a.args) > 0 {
+				tags := splitList(strings.Join(a.args, " "))
+				if currentMsg != nil {
+					currentMsg.Tags = tags
+				} else {
+					socket.Tags = tags
 				}
-				socket.Tags = tags
 			}
 		case "@ConnectionParam":
-			if len(fields) >= 5 {
-				param := spec.ConnectionParam{
-					Name:     fields[1],
-					In:       fields[2],
-					Type:     fields[3],
-					Required: fields[4] == "required",
+			// @ConnectionParam <name> <in> <type> <required|optional> [description]
+			// or with options: @ConnectionParam <name> in=header type=string required=true
+			if len(a.args) > 0 {
+				param := spec.ConnectionParam{Name: a.arg(0)}
+				in, hasIn := a.opt("in")
+				typ, hasType := a.opt("type")
+				required, hasRequired := a.opt("required")
+				if hasIn || hasType || hasRequired {
+					// Options replace the positional arguments, so the description follows the name
+					param.In, param.Type, param.Required = in, typ, required == "true"
+					param.Description = a.rest(1)
+				} else {
+					param.In, param.Type, param.Required = a.arg(1), a.arg(2), a.arg(3) == "required"
+					param.Description = a.rest(4)
 				}
-				if len(fields) > 5 {
-					param.Description = strings.Join(fields[5:], " ")
+				if param.In != "" && param.Type != "" {
+					socket.ConnectionParams = append(socket.ConnectionParams, param)
 				}
-				socket.ConnectionParams = append(socket.ConnectionParams, param)
 			}
-		case "@Message":
-			// Add previous message to grouped structure if exists
-			if currentMsg != nil && currentType != "" && currentDirection != "" {
-				if currentDirection == "send" {
-					messageGroups[currentType].Send = currentMsg
-					if messageGroups[currentType].Description == "" {
-						messageGroups[currentType].Description = currentMsg.Description
-					}
-				} else if currentDirection == "receive" {
-					messageGroups[currentType].Receive = currentMsg
-					if messageGroups[currentType].Description == "" {
-						messageGroups[currentType].Description = currentMsg.Description
+		case "@Handshake":
+			// @Handshake <status> [json body] [description], the body may also follow in a fenced block
+			body, lang, fenced := a.fence()
+			arg := a.raw
+			if !fenced {
+				arg, _ = a.value()
+			}
+			if resp, ok := parseHandshakeResponse(arg); ok {
+				if fenced {
+					resp.Body, _ = decodeValue(body, lang)
+				}
+				socket.Handshake = append(socket.Handshake, resp)
+			}
+		case "@Heartbeat":
+			// @Heartbeat <ping|application> <interval> [timeout] [message]
+			hb := &spec.Heartbeat{Mode: a.arg(0), Interval: a.arg(1), Timeout: a.arg(2), Message: a.arg(3)}
+			if v, ok := a.opt("interval"); ok {
+				hb.Interval = v
+			}
+			if v, ok := a.opt("timeout"); ok {
+				hb.Timeout = v
+			}
+			if v, ok := a.opt("message"); ok {
+				hb.Message = v
+			}
+			if hb.Mode != "" && hb.Interval != "" {
+				socket.Heartbeat = hb
+			}
+		case "@IdleTimeout":
+			if len(a.args) > 0 {
+				socket.IdleTimeout = a.args[0]
+			}
+		case "@MaxMessageSize":
+			if len(a.args) > 0 {
+				if size, ok := parseByteSize(a.args[0]); ok {
+					socket.MaxMessageSize = size
+				}
+			}
+		case "@RateLimit":
+			// @RateLimit <messages>/<window> [description]
+			if len(a.args) > 0 {
+				if rl, ok := parseRateLimit(a.args[0]); ok {
+					rl.Description = a.rest(1)
+					socket.RateLimit = rl
+				}
+			}
+		case "@CloseCode":
+			if len(a.args) > 0 {
+				if code, err := strconv.Atoi(a.args[0]); err == nil {
+					socket.CloseCodes = append(socket.CloseCodes, spec.CloseCode{
+						Code:        code,
+						Description: a.rest(1),
+					})
+				}
+			}
+		case "@State":
+			// @State <name> [initial] [final] [description]
+			if len(a.args) > 0 {
+				if socket.Protocol == nil {
+					socket.Protocol = &spec.Protocol{}
+				}
+				st := spec.State{Name: a.args[0]}
+				rest := 1
+				for rest < len(a.args) && (a.args[rest] == "initial" || a.args[rest] == "final") {
+					if a.args[rest] == "initial" {
+						socket.Protocol.Initial = st.Name
+					} else {
+						st.Final = true
 					}
+					rest++
+				}
+				st.Description = a.rest(rest)
+				socket.Protocol.States = append(socket.Protocol.States, st)
+			}
+		case "@Transition":
+			// @Transition <from> -> <to> on <message> [description]
+			var args []string
+			rest := len(a.args)
+			for k, f := range a.args {
+				if len(args) == 3 {
+					rest = k
+					break
+				}
+				if f != "->" && f != "on" {
+					args = append(args, f)
 				}
 			}
-			currentType = ""
-			if len(fields) > 1 {
-				currentType = fields[1]
+			if len(args) >= 3 {
+				if socket.Protocol == nil {
+					socket.Protocol = &spec.Protocol{}
+				}
+				socket.Protocol.Transitions = append(socket.Protocol.Transitions, spec.Transition{
+					From:        args[0],
+					To:          args[1],
+					Message:     args[2],
+					Description: a.rest(rest),
+				})
 			}
+		case "@Message":
+			flush()
+			currentType, currentMsg, currentDirection, examples = a.arg(0), nil, "", nil
 			if messageGroups[currentType] == nil {
 				messageGroups[currentType] = &spec.GroupedMessage{Type: currentType}
+				messageOrder = append(messageOrder, currentType)
 			}
-		case "@Send":
-			// Add previous message to grouped structure if exists
-			if currentMsg != nil && currentType != "" && currentDirection != "" {
-				if currentDirection == "send" {
-					messageGroups[currentType].Send = currentMsg
-					if messageGroups[currentType].Description == "" {
-						messageGroups[currentType].Description = currentMsg.Description
-					}
-				} else if currentDirection == "receive" {
-					messageGroups[currentType].Receive = currentMsg
-					if messageGroups[currentType].Description == "" {
-						messageGroups[currentType].Description = currentMsg.Description
-					}
-				}
+			if v, ok := a.opt("deprecated"); ok {
+				messageGroups[currentType].Deprecated = v != "false"
+			}
+			if v, ok := a.opt("tags"); ok {
+				messageGroups[currentType].Tags = splitList(v)
 			}
+		case "@Send":
+			flush()
 			currentDirection = "send"
 			currentMsg = &spec.Message{Type: currentType, Direction: "send"}
+			examples = &currentMsg.Examples
 		case "@Receive":
-			// Add previous message to grouped structure if exists
-			if currentMsg != nil && currentType != "" && currentDirection != "" {
-				if currentDirection == "send" {
-					messageGroups[currentType].Send = currentMsg
-					if messageGroups[currentType].Description == "" {
-						messageGroups[currentType].Description = currentMsg.Description
-					}
-				} else if currentDirection == "receive" {
-					messageGroups[currentType].Receive = currentMsg
-					if messageGroups[currentType].Description == "" {
-						messageGroups[currentType].Description = currentMsg.Description
-					}
-				}
-			}
+			flush()
 			currentDirection = "receive"
 			currentMsg = &spec.Message{Type: currentType, Direction: "receive"}
+			examples = &currentMsg.Examples
 		case "@Payload":
 			if currentMsg != nil {
-				payloadArg := strings.TrimSpace(strings.TrimPrefix(line, "@Payload"))
+				payloadArg, lang := a.value()
 				if payloadArg != "" {
-					// Check if it's inline JSON (starts with { or [)
-					if strings.HasPrefix(payloadArg, "{") || strings.HasPrefix(payloadArg, "[") {
-						// Parse inline JSON
-						var jsonPayload interface{}
-						if err := json.Unmarshal([]byte(payloadArg), &jsonPayload); err == nil {
-							if b, err := json.Marshal(jsonPayload); err == nil {
-								currentMsg.Payload = string(b)
-							} else {
-								currentMsg.Payload = jsonPayload
-							}
+					// Check if it's inline JSON (starts with { or [) or a fenced block
+					if lang != "" || strings.HasPrefix(payloadArg, "{") || strings.HasPrefix(payloadArg, "[") {
+						if payload, ok := decodePayload(payloadArg, lang); ok {
+							currentMsg.Payload = payload
 						} else {
 							currentMsg.Payload = payloadArg // fallback to raw string
 						}
-					} else {
+					} else if payload, ok := structPayload(structMap, payloadArg); ok {
 						// Treat as struct name
-						structName := payloadArg
-						found := false
-						if s, ok := structMap[structName]; ok {
-							if s.Fields != nil {
-								if b, err := json.Marshal(s.Fields); err == nil {
-									currentMsg.Payload = string(b)
-								} else {
-									currentMsg.Payload = s.Fields // fallback
-								}
-							}
-							found = true
-						}
-						if !found {
-							for k, s := range structMap {
-								if strings.HasSuffix(k, "."+structName) {
-									if s.Fields != nil {
-										if b, err := json.Marshal(s.Fields); err == nil {
-											currentMsg.Payload = string(b)
-										} else {
-											currentMsg.Payload = s.Fields
-										}
-									}
-									found = true
-									break
-								}
-							}
-						}
-						// If not found, do not attempt fuzzy/contains match. Leave payload empty or log warning.
+						currentMsg.Payload = payload
+						currentMsg.Schema = structSchema(structMap, payloadArg)
 					}
 				}
 			}
 		case "@Error":
-			if currentMsg != nil && len(fields) > 1 {
-				err := spec.Error{Code: fields[1]}
-				if len(fields) > 2 {
-					err.Description = strings.Join(fields[2:], " ")
-				}
-				currentMsg.Errors = append(currentMsg.Errors, err)
+			if currentMsg != nil && len(a.args) > 0 {
+				currentMsg.Errors = append(currentMsg.Errors, spec.Error{Code: a.args[0], Description: a.rest(1)})
+				examples = &currentMsg.Errors[len(currentMsg.Errors)-1].Examples
+			}
+		case "@Example":
+			// @Example <name> applies to the last @Error, else to the message
+			if ex, ok := parseExample(a); ok && examples != nil {
+				*examples = append(*examples, ex)
 			}
 		case "@Deprecated":
 			if currentMsg != nil {
@@ -411,22 +592,11 @@ func parseSocketBlock(block []string) *spec.Socket {
 		}
 	}
 	// Add the last message if exists
-	if currentMsg != nil && currentType != "" && currentDirection != "" {
-		if currentDirection == "send" {
-			messageGroups[currentType].Send = currentMsg
-			if messageGroups[currentType].Description == "" {
-				messageGroups[currentType].Description = currentMsg.Description
-			}
-		} else if currentDirection == "receive" {
-			messageGroups[currentType].Receive = currentMsg
-			if messageGroups[currentType].Description == "" {
-				messageGroups[currentType].Description = currentMsg.Description
-			}
-		}
-	}
-	// Convert grouped messages to slice and add to socket
-	for _, groupedMsg := range messageGroups {
-		socket.GroupedMessages = append(socket.GroupedMessages, *groupedMsg)
+	flush()
+	addPathParams(socket)
+	// Convert grouped messages to slice and add to socket, in the order they were declared
+	for _, t := range messageOrder {
+		socket.GroupedMessages = append(socket.GroupedMessages, *messageGroups[t])
 	}
 	// For backward compatibility, also populate the old Messages field
 	for _, groupedMsg := range socket.GroupedMessages {
@@ -440,61 +610,219 @@ func parseSocketBlock(block []string) *spec.Socket {
 	return socket
 }
 
-// parseJSONBlock joins lines and parses JSON, returns map or array or string.
-func parseJSONBlock(lines []string) interface{} {
-	joined := strings.Join(lines, "\n")
-	var v interface{}
-	if err := json.Unmarshal([]byte(joined), &v); err != nil {
-		return joined // fallback: raw string
+// structPayload returns the example payload of a struct, looked up by name or
+// package-qualified name. Unknown structs leave the payload empty.
+func structPayload(structMap map[string]StructInfo, structName string) (interface{}, bool) {
+	s, ok := lookupStruct(structMap, structName)
+	if ok && (s.override != nil || s.marshal != "") {
+		if v, ok := schemaExample(s.typeSchema(structMap, 0)); ok {
+			if b, err := json.Marshal(v); err == nil {
+				return string(b), true
+			}
+		}
+		return nil, true
+	}
+	if !ok || s.Fields == nil {
+		return nil, ok
+	}
+	s = s.encoded(structMap, 0)
+	if b, err := s.JSON(); err == nil {
+		return string(b), true
+	}
+	return s.Fields, true
+}
+
+// parseExample parses `@Example <name> [description]` followed by a fenced
+// JSON or YAML block, or `@Example <name> <inline JSON>`.
+func parseExample(a annotation) (spec.Example, bool) {
+	toks := tokenize(a.raw)
+	if len(toks) == 0 {
+		return spec.Example{}, false
 	}
-	return v
+	ex := spec.Example{Name: toks[0].value}
+	inline := strings.TrimSpace(strings.TrimPrefix(a.raw, toks[0].text))
+	if strings.HasPrefix(inline, "{") || strings.HasPrefix(inline, "[") {
+		v, ok := decodeValue(joinText(inline, a.continuation()), "")
+		ex.Value = v
+		return ex, ok
+	}
+	body, lang, ok := a.fence()
+	if !ok {
+		return spec.Example{}, false
+	}
+	if ex.Value, ok = decodeValue(body, lang); !ok {
+		return spec.Example{}, false
+	}
+	ex.Description = strings.Join(a.args[1:], " ")
+	if d, ok := a.opt("description"); ok {
+		ex.Description = d
+	}
+	return ex, true
 }
 
-// ParseInfoAnnotations scans Go files in srcDir for top-level API info annotations.
-func ParseInfoAnnotations(srcDir string) spec.Info {
-	info := spec.Info{}
-	filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
-			return nil
+// exampleFiles replaces `file:<path>` references in @Example lines with a
+// fenced block holding the file's content. Paths are relative to dir, the
+// directory of the Go file the lines come from.
+func exampleFiles(lines []string, dir string) ([]string, []error) {
+	var out []string
+	var errs []error
+	for _, line := range lines {
+		a := strings.TrimSpace(line)
+		if !strings.HasPrefix(a, "@Example ") || !strings.Contains(a, "file:") {
+			out = append(out, line)
+			continue
 		}
-		f, err := os.Open(path)
+		var kept []string
+		var ref string
+		for _, tok := range tokenize(strings.TrimPrefix(a, "@Example ")) {
+			if p, ok := strings.CutPrefix(tok.text, "file:"); ok && ref == "" {
+				ref = p
+				continue
+			}
+			kept = append(kept, tok.text)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, ref))
 		if err != nil {
-			return nil
-		}
-		defer f.Close()
-		lines := []string{}
-		scanner := bufio.NewScanner(f)
-		for scanner.Scan() {
-			lines = append(lines, scanner.Text())
-		}
-		// 1. Dosya başındaki consecutive annotation'ları tara
-		for _, line := range lines {
-			l := strings.TrimSpace(strings.TrimPrefix(line, "//"))
-			if !strings.HasPrefix(l, "@") {
-				break
-			}
-			parseInfoLine(&info, l)
-		}
-		// 2. main fonksiyonu üstündeki consecutive annotation block'u tara
-		for i := 0; i < len(lines); i++ {
-			if strings.Contains(lines[i], "func main(") {
-				// Yukarıya doğru consecutive //@ annotation'ları topla
-				for j := i - 1; j >= 0; j-- {
-					l := strings.TrimSpace(strings.TrimPrefix(lines[j], "//"))
-					if strings.HasPrefix(l, "@") {
-						parseInfoLine(&info, l)
-					} else if l == "" {
-						continue
-					} else {
-						break
-					}
+			errs = append(errs, fmt.Errorf("@Example %s: %w", strings.Join(kept, " "), err))
+			continue
+		}
+		lang := "json"
+		if ext := filepath.Ext(ref); ext == ".yaml" || ext == ".yml" {
+			lang = "yaml"
+		}
+		out = append(out, "@Example "+strings.Join(kept, " "), "```"+lang)
+		out = append(out, strings.Split(strings.TrimRight(string(data), "\n"), "\n")...)
+		out = append(out, "```", "")
+	}
+	return out, errs
+}
+
+// lookupStruct finds a struct by name, qualified or not. An unqualified name
+// not declared in the scanned package matches the first qualified name in
+// sorted order, so the choice is the same on every run.
+func lookupStruct(structMap map[string]StructInfo, structName string) (StructInfo, bool) {
+	if s, ok := structMap[structName]; ok {
+		return s, true
+	}
+	var match string
+	for k := range structMap {
+		if strings.HasSuffix(k, "."+structName) && (match == "" || k < match) {
+			match = k
+		}
+	}
+	s, ok := structMap[match]
+	return s, ok
+}
+
+// addPathParams declares a path parameter for every URL placeholder that is not documented yet.
+func addPathParams(socket *spec.Socket) {
+	for _, name := range spec.Placeholders(socket.URL) {
+		if socket.Param(name) == nil {
+			socket.ConnectionParams = append(socket.ConnectionParams, spec.ConnectionParam{
+				Name:     name,
+				In:       "path",
+				Type:     "string",
+				Required: true,
+			})
+		}
+	}
+}
+
+// parseHandshakeResponse parses "<status> [json body] [description]" from a @Handshake annotation.
+func parseHandshakeResponse(arg string) (spec.HandshakeResponse, bool) {
+	resp := spec.HandshakeResponse{}
+	statusStr, rest, _ := strings.Cut(arg, " ")
+	status, err := strconv.Atoi(statusStr)
+	if err != nil {
+		return resp, false
+	}
+	resp.Status = status
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, "{") || strings.HasPrefix(rest, "[") {
+		dec := json.NewDecoder(strings.NewReader(rest))
+		var body interface{}
+		if err := dec.Decode(&body); err == nil {
+			resp.Body = body
+			rest = strings.TrimSpace(rest[dec.InputOffset():])
+		}
+	}
+	resp.Description = rest
+	return resp, true
+}
+
+// parseByteSize parses a size such as 65536, 64KB or 1MB into bytes (KB and MB are multiples of 1024).
+func parseByteSize(s string) (int64, bool) {
+	upper := strings.ToUpper(s)
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		mult   int64
+	}{{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"KB", 1 << 10}, {"MB", 1 << 20}, {"K", 1 << 10}, {"M", 1 << 20}, {"B", 1}} {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper = strings.TrimSuffix(upper, unit.suffix)
+			multiplier = unit.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(upper), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n * multiplier, true
+}
+
+// parseRateLimit parses "<messages>/<window>" such as 20/s, 100/1m or 5/10s.
+func parseRateLimit(s string) (*spec.RateLimit, bool) {
+	countStr, window, ok := strings.Cut(s, "/")
+	if !ok {
+		return nil, false
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		return nil, false
+	}
+	switch window {
+	case "s", "sec", "second":
+		window = "1s"
+	case "m", "min", "minute":
+		window = "1m"
+	case "h", "hour":
+		window = "1h"
+	}
+	return &spec.RateLimit{Messages: count, Window: window}, true
+}
+
+// infoLines returns the API info annotation lines of a file in the order they apply.
+func infoLines(lines []string) []string {
+	var out []string
+	// 1. Dosya başındaki consecutive annotation'ları tara
+	for _, line := range lines {
+		l := strings.TrimSpace(strings.TrimPrefix(line, "//"))
+		if !strings.HasPrefix(l, "@") {
+			break
+		}
+		out = append(out, l)
+	}
+	// 2. main fonksiyonu üstündeki consecutive annotation block'u tara
+	for i := 0; i < len(lines); i++ {
+		if strings.Contains(lines[i], "func main(") {
+			// Yukarıya doğru consecutive //@ annotation'ları topla
+			var block []string
+			for j := i - 1; j >= 0; j-- {
+				l := strings.TrimSpace(strings.TrimPrefix(lines[j], "//"))
+				if strings.HasPrefix(l, "@") {
+					block = append([]string{l}, block...)
+				} else if l == "" {
+					continue
+				} else {
+					break
 				}
-				break
 			}
+			out = append(out, block...)
+			break
 		}
-		return nil
-	})
-	return info
+	}
+	return out
 }
 
 // parseInfoLine yardımcı fonksiyonu
@@ -521,40 +849,38 @@ func parseInfoLine(info *spec.Info, line string) {
 	}
 }
 
-// ParseAndWriteSpec parses Go files in srcDir and writes the spec to outFile (YAML).
-func ParseAndWriteSpec(srcDir, outFile string) error {
-	sockets, err := Parse(srcDir)
-	if err != nil {
-		return err
-	}
-	info := ParseInfoAnnotations(srcDir)
-	if info.Title == "" {
-		info.Title = "WebSocket API"
-	}
-	if info.Version == "" {
-		info.Version = "1.0.0"
-	}
-	if info.Description == "" {
-		info.Description = "Generated by wsdoc"
-	}
-	s := spec.Spec{
-		Info:    info,
-		Sockets: []spec.Socket{},
-	}
-	for _, sock := range sockets {
-		s.Sockets = append(s.Sockets, *sock)
-	}
-
-	f, err := os.Create(outFile)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	enc := yaml.NewEncoder(f)
-	enc.SetIndent(2)
-	if err := enc.Encode(&s); err != nil {
-		return err
+// parseServerLine applies an @server or @server.variable annotation to servers.
+//
+//	@server <name> <url> [description]
+//	@server.variable <server> <name> <default> [enum=a,b,c] [description]
+func parseServerLine(servers *[]spec.Server, line string) {
+	fields := strings.Fields(line)
+	switch {
+	case fields[0] == "@server" && len(fields) >= 3:
+		srv := spec.Server{Name: fields[1], URL: fields[2], Description: strings.Join(fields[3:], " ")}
+		for i := range *servers {
+			if (*servers)[i].Name == srv.Name {
+				srv.Variables = (*servers)[i].Variables
+				(*servers)[i] = srv
+				return
+			}
+		}
+		*servers = append(*servers, srv)
+	case fields[0] == "@server.variable" && len(fields) >= 4:
+		v := spec.ServerVariable{Name: fields[2], Default: fields[3]}
+		rest := fields[4:]
+		if len(rest) > 0 && strings.HasPrefix(rest[0], "enum=") {
+			v.Enum = strings.Split(strings.TrimPrefix(rest[0], "enum="), ",")
+			rest = rest[1:]
+		}
+		v.Description = strings.Join(rest, " ")
+		for i := range *servers {
+			if (*servers)[i].Name == fields[1] {
+				(*servers)[i].Variables = append((*servers)[i].Variables, v)
+				return
+			}
+		}
+		// Variables may be declared before their server.
+		*servers = append(*servers, spec.Server{Name: fields[1], Variables: []spec.ServerVariable{v}})
 	}
-	return nil
 }
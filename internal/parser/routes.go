@@ -0,0 +1,240 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// route is a handler registration such as r.GET("/ws/chat", chatHandler).
+type route struct {
+	path    string
+	handler string
+	pos     token.Position
+}
+
+// handlerFunc is a function found in a file, e.g. one that upgrades connections.
+type handlerFunc struct {
+	name string // receiver-qualified
+	recv string // receiver type of a method
+	pos  token.Position
+}
+
+// routeMethods are the registration methods of gin, chi, echo and net/http that
+// can serve a WebSocket upgrade (a GET request).
+var routeMethods = map[string]bool{
+	"GET": true, "Get": true, "Any": true, "Match": true,
+	"Handle": true, "HandleFunc": true, "Method": true, "MethodFunc": true,
+}
+
+// handlerWrappers adapt a handler without changing which function serves the route.
+var handlerWrappers = map[string]bool{
+	"Handler": true, "HandlerFunc": true, "WrapF": true, "WrapH": true, "WrapHandler": true,
+}
+
+// fileRoutes finds route registrations in a file. Prefixes from gin/echo groups
+// assigned to variables (v1 := r.Group("/api")) and from chi's r.Route("/api", func(r chi.Router) {...})
+// are applied.
+func fileRoutes(fset *token.FileSet, file *ast.File) []route {
+	var routes []route
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		routes = append(routes, blockRoutes(fset, fn.Body, map[string]string{}, "")...)
+	}
+	return routes
+}
+
+// blockRoutes collects routes registered in a function body. groups maps router
+// variables to their path prefix; prefix applies to every router in the block.
+func blockRoutes(fset *token.FileSet, body *ast.BlockStmt, groups map[string]string, prefix string) []route {
+	var routes []route
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			// v1 := r.Group("/api/v1")
+			if len(n.Lhs) != 1 || len(n.Rhs) != 1 {
+				return true
+			}
+			name, ok := n.Lhs[0].(*ast.Ident)
+			call, isCall := n.Rhs[0].(*ast.CallExpr)
+			if !ok || !isCall {
+				return true
+			}
+			if recv, method, ok := selector(call.Fun); ok && method == "Group" && len(call.Args) > 0 {
+				if p, ok := stringLit(call.Args[0]); ok {
+					groups[name.Name] = groups[recv] + p
+				}
+			}
+		case *ast.CallExpr:
+			recv, method, ok := selector(n.Fun)
+			if !ok {
+				return true
+			}
+			// r.Route("/api", func(r chi.Router) { ... })
+			if method == "Route" && len(n.Args) == 2 {
+				p, ok := stringLit(n.Args[0])
+				lit, isLit := n.Args[1].(*ast.FuncLit)
+				if ok && isLit {
+					routes = append(routes, blockRoutes(fset, lit.Body, copyGroups(groups), prefix+groups[recv]+p)...)
+					return false
+				}
+			}
+			if !routeMethods[method] || len(n.Args) < 2 {
+				return true
+			}
+			path, ok := routePath(n.Args[:len(n.Args)-1])
+			if !ok {
+				return true
+			}
+			handler := handlerName(n.Args[len(n.Args)-1])
+			if handler == "" {
+				return true
+			}
+			routes = append(routes, route{
+				path:    prefix + groups[recv] + path,
+				handler: handler,
+				pos:     fset.Position(n.Pos()),
+			})
+		}
+		return true
+	})
+	return routes
+}
+
+func copyGroups(groups map[string]string) map[string]string {
+	c := make(map[string]string, len(groups))
+	for k, v := range groups {
+		c[k] = v
+	}
+	return c
+}
+
+// routePath returns the path among a registration's arguments, dropping the
+// method of Go 1.22 patterns like "GET /ws/{room}".
+func routePath(args []ast.Expr) (string, bool) {
+	for i := len(args) - 1; i >= 0; i-- {
+		s, ok := stringLit(args[i])
+		if !ok {
+			continue
+		}
+		if method, path, found := strings.Cut(s, " "); found && method == strings.ToUpper(method) {
+			s = strings.TrimSpace(path)
+		}
+		if strings.HasPrefix(s, "/") {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// handlerName returns the function serving a route: chatHandler, h.Chat and
+// pkg.Chat all name the function, http.HandlerFunc(chat) is unwrapped, a
+// factory call such as serveWs(hub) names the factory, and &ChatHandler{}
+// names the handler type.
+func handlerName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.UnaryExpr:
+		return handlerName(e.X)
+	case *ast.CompositeLit:
+		return typeName(e.Type)
+	case *ast.CallExpr:
+		if _, method, ok := selector(e.Fun); ok && handlerWrappers[method] && len(e.Args) == 1 {
+			return handlerName(e.Args[0])
+		}
+		return handlerName(e.Fun)
+	}
+	return ""
+}
+
+// upgradingFuncs returns the functions in a file that upgrade HTTP connections to WebSockets.
+func upgradingFuncs(fset *token.FileSet, file *ast.File) []handlerFunc {
+	var funcs []handlerFunc
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		upgrades := false
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return !upgrades
+			}
+			recv, method, ok := selector(call.Fun)
+			switch {
+			case !ok:
+			case method == "Upgrade", method == "UpgradeHTTP":
+				upgrades = true
+			case method == "Accept" && recv == "websocket":
+				upgrades = true
+			}
+			return !upgrades
+		})
+		if upgrades {
+			funcs = append(funcs, handlerFunc{name: funcKey(fn), recv: recvType(fn), pos: fset.Position(fn.Pos())})
+		}
+	}
+	return funcs
+}
+
+// selector splits x.Method into the receiver identifier (empty if not an identifier) and method name.
+func selector(expr ast.Expr) (string, string, bool) {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return "", "", false
+	}
+	recv := ""
+	if id, ok := sel.X.(*ast.Ident); ok {
+		recv = id.Name
+	}
+	return recv, sel.Sel.Name, true
+}
+
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+var routeParam = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)|\{([^{}]+?)(\.\.\.)?\}|\*[A-Za-z0-9_]*$`)
+
+// normalizeRoutePath turns a route or @URL into a comparable path: the scheme
+// and host are dropped, and :id, {id}, {id...} and *wildcards all become {}.
+func normalizeRoutePath(p string) string {
+	if _, rest, ok := strings.Cut(p, "://"); ok {
+		p = "/"
+		if i := strings.Index(rest, "/"); i >= 0 {
+			p = rest[i:]
+		}
+	}
+	p = strings.TrimSuffix(p, "{$}")
+	if i := strings.IndexAny(p, "?#"); i >= 0 {
+		p = p[:i]
+	}
+	p = routeParam.ReplaceAllString(p, "{}")
+	if len(p) > 1 {
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p
+}
+
+var colonParam = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)|\{([^{}]+?)\.\.\.\}`)
+
+// routeURL converts a route path to an @URL: gin/echo :params and net/http
+// {param...} wildcards become {param} placeholders.
+func routeURL(p string) string {
+	p = strings.TrimSuffix(p, "{$}")
+	return colonParam.ReplaceAllString(p, "{$1$2}")
+}
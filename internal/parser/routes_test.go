@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/muratmirgun/socketeer/internal/spec"
+)
+
+func TestFileRoutes(t *testing.T) {
+	// Each route is "<path> <handler> <line>"
+	tests := map[string][]string{
+		"gin.go": {
+			"/ws/chat chatHandler 5",
+			"/ws/rooms/:room Room 6",
+			"/ws/any anyHandler 7",
+		},
+		"gin_groups.go": {
+			"/api/v1/ws/orders/:id Stream 6",
+			"/api/ws/*path wildcard 7",
+		},
+		"chi.go": {
+			"/ws/feed feedHandler 5",
+			"/api/v2/ws/{room} roomHandler 8",
+			"/api/ws/method methodHandler 10",
+		},
+		"echo.go": {
+			"/ws/notify/:user notify 5",
+			"/admin/ws/audit Serve 7",
+			"/ws/match matchHandler 8",
+		},
+		"nethttp.go": {
+			"/ws/echo echoHandler 5",
+			"/ws/rooms/{room}/{rest...} serveWs 6",
+			"/ws/typed TypedHandler 7",
+			"/ws/exact/{$} exact 8",
+		},
+	}
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, filepath.Join("testdata", "routes", name), nil, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range fileRoutes(fset, file) {
+				got = append(got, fmt.Sprintf("%s %s %d", r.path, r.handler, r.pos.Line))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("routes of %s = %q, want %q", name, got, want)
+			}
+		})
+	}
+}
+
+func TestRouteURL(t *testing.T) {
+	tests := map[string]string{
+		"/ws/chat":                   "/ws/chat",
+		"/ws/rooms/:room":            "/ws/rooms/{room}",
+		"/api/v1/ws/orders/:id/:sub": "/api/v1/ws/orders/{id}/{sub}",
+		"/ws/{room}":                 "/ws/{room}",
+		"/ws/rooms/{room}/{rest...}": "/ws/rooms/{room}/{rest}",
+		"/ws/exact/{$}":              "/ws/exact/",
+	}
+	for in, want := range tests {
+		if got := routeURL(in); got != want {
+			t.Errorf("routeURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalizeRoutePath(t *testing.T) {
+	tests := map[string]string{
+		"ws://localhost:8080/ws/chat": "/ws/chat",
+		"wss://example.com":           "/",
+		"/ws/rooms/:room":             "/ws/rooms/{}",
+		"/ws/rooms/{room}":            "/ws/rooms/{}",
+		"/ws/rooms/{rest...}":         "/ws/rooms/{}",
+		"/api/ws/*path":               "/api/ws/{}",
+		"/ws/chat/?token=x":           "/ws/chat",
+		"/ws/exact/{$}":               "/ws/exact",
+	}
+	for in, want := range tests {
+		if got := normalizeRoutePath(in); got != want {
+			t.Errorf("normalizeRoutePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAddPathParams(t *testing.T) {
+	socket := &spec.Socket{
+		URL: "/ws/rooms/{room}/{user}",
+		ConnectionParams: []spec.ConnectionParam{
+			{Name: "user", In: "path", Type: "integer", Required: true, Description: "User ID"},
+		},
+	}
+	addPathParams(socket)
+	want := []spec.ConnectionParam{
+		{Name: "user", In: "path", Type: "integer", Required: true, Description: "User ID"},
+		{Name: "room", In: "path", Type: "string", Required: true},
+	}
+	if !reflect.DeepEqual(socket.ConnectionParams, want) {
+		t.Errorf("connection params = %+v, want %+v", socket.ConnectionParams, want)
+	}
+}
+
+func TestUpgradingFuncs(t *testing.T) {
+	const src = `package main
+
+func (h *Hub) Serve(w http.ResponseWriter, r *http.Request) {
+	conn, _ := upgrader.Upgrade(w, r, nil)
+	_ = conn
+}
+
+func accept(w http.ResponseWriter, r *http.Request) {
+	websocket.Accept(w, r, nil)
+}
+
+func plain(w http.ResponseWriter, r *http.Request) {}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range upgradingFuncs(fset, file) {
+		got = append(got, f.name+" "+f.recv)
+	}
+	if want := []string{"Hub.Serve Hub", "accept "}; !reflect.DeepEqual(got, want) {
+		t.Errorf("upgrading funcs = %q, want %q", got, want)
+	}
+}
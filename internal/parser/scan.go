@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"errors"
+	"go/build"
+	"go/scanner"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// selected reports whether a file is scanned: it is a non-test Go file below
+// one of the index's directories, outside skipped directories, matches Include
+// if set, does not match Exclude and satisfies its build constraints.
+func (ix *Index) selected(file string) bool {
+	if !isSourceFile(file) {
+		return false
+	}
+	if rel, ok := ix.relPath(file); ok {
+		elems := strings.Split(rel, "/")
+		for _, dir := range elems[:len(elems)-1] {
+			if skippedDir(dir) {
+				return false
+			}
+		}
+		if matchAny(ix.Exclude, rel) || len(ix.Include) > 0 && !matchAny(ix.Include, rel) {
+			return false
+		}
+	}
+	ctx := build.Default
+	ctx.BuildTags = ix.Tags
+	ok, err := ctx.MatchFile(filepath.Dir(file), filepath.Base(file))
+	return ok || err != nil // unreadable files are reported by the parser
+}
+
+// skipDir reports whether a directory below a root is left out of the scan:
+// skipped names like vendor, excluded directories and other modules of a workspace.
+func (ix *Index) skipDir(dir string) bool {
+	if skippedDir(filepath.Base(dir)) || slices.Contains(ix.roots, dir) {
+		return true
+	}
+	if rel, ok := ix.relPath(dir); ok && matchAny(ix.Exclude, rel) {
+		return true
+	}
+	if !ix.workspace {
+		return false
+	}
+	// In a workspace, nested modules are only scanned if the workspace uses them
+	_, err := os.Stat(filepath.Join(dir, "go.mod"))
+	return err == nil
+}
+
+// skippedDir reports whether the go tool ignores a directory name: vendor,
+// testdata and names starting with a dot or underscore.
+func skippedDir(name string) bool {
+	return name == "vendor" || name == "testdata" || name != "." && name != ".." && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_"))
+}
+
+// workspaceRoots replaces directories containing a go.work file by the
+// module directories of the workspace, and reports whether there was one.
+func workspaceRoots(dirs []string) ([]string, bool, error) {
+	var roots []string
+	workspace := false
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, "go.work"))
+		if errors.Is(err, fs.ErrNotExist) {
+			roots = append(roots, filepath.Clean(dir))
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		workspace = true
+		for _, use := range workspaceUses(string(data)) {
+			if !filepath.IsAbs(use) {
+				use = filepath.Join(dir, use)
+			}
+			roots = append(roots, filepath.Clean(use))
+		}
+	}
+	return roots, workspace, nil
+}
+
+// workspaceUses returns the directories of the use directives of a go.work file,
+// written as `use ./api` or as a `use ( ... )` block.
+func workspaceUses(work string) []string {
+	var uses []string
+	inBlock := false
+	for _, line := range strings.Split(work, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case inBlock && fields[0] == ")":
+			inBlock = false
+		case inBlock:
+			uses = append(uses, unquote(fields[0]))
+		case fields[0] == "use" && len(fields) > 1 && fields[1] == "(":
+			inBlock = true
+		case fields[0] == "use" && len(fields) > 1:
+			uses = append(uses, unquote(fields[1]))
+		}
+	}
+	return uses
+}
+
+// parseFailure reports a file that could not be read or parsed, at the first syntax error.
+func parseFailure(err error) Diagnostic {
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		return Diagnostic{Pos: list[0].Pos, Severity: SeverityError, Message: list[0].Msg}
+	}
+	return Diagnostic{Severity: SeverityError, Message: err.Error()}
+}
+
+// relPath returns a path relative to the source dir containing it, with forward slashes.
+func (ix *Index) relPath(file string) (string, bool) {
+	for _, dir := range ix.roots {
+		rel, err := filepath.Rel(dir, file)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel), true
+		}
+	}
+	return "", false
+}
+
+// matchAny reports whether a slash-separated relative path matches one of the
+// globs. A glob containing a slash is matched against the whole path, with **
+// matching any number of directories; other globs are matched against each
+// element, so "vendor" or "*_gen.go" match at any depth.
+func matchAny(globs []string, rel string) bool {
+	elems := strings.Split(rel, "/")
+	for _, g := range globs {
+		g = strings.TrimSuffix(filepath.ToSlash(g), "/")
+		if !strings.Contains(g, "/") {
+			for _, e := range elems {
+				if ok, _ := path.Match(g, e); ok {
+					return true
+				}
+			}
+			continue
+		}
+		if matchElems(strings.Split(strings.TrimPrefix(g, "./"), "/"), elems) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchElems matches path elements against glob elements. A trailing match
+// on a directory covers everything below it.
+func matchElems(glob, elems []string) bool {
+	if len(glob) == 0 {
+		return true
+	}
+	if glob[0] == "**" {
+		for i := 0; i <= len(elems); i++ {
+			if matchElems(glob[1:], elems[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(elems) == 0 {
+		return false
+	}
+	ok, _ := path.Match(glob[0], elems[0])
+	return ok && matchElems(glob[1:], elems[1:])
+}
@@ -0,0 +1,194 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// writeTree creates files under dir, keyed by slash-separated path.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// scanned loads an index and returns the files it parsed, relative to dir.
+func scanned(t *testing.T, ix *Index, dir string) []string {
+	t.Helper()
+	if err := ix.Load(); err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, p := range ix.paths() {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, filepath.ToSlash(rel))
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestIndexScan(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"go.mod":               "module example.com/app\n",
+		"main.go":              "package main\n",
+		"main_test.go":         "package main\n",
+		"notes.txt":            "not Go\n",
+		"extra.go":             "//go:build socketeer_extra\n\npackage main\n",
+		"legacy.go":            "//go:build !socketeer_extra\n\npackage main\n",
+		"vendor/dep/dep.go":    "package dep\n",
+		"testdata/fixture.go":  "package fixture\n",
+		".cache/cached.go":     "package cached\n",
+		"_old/old.go":          "package old\n",
+		"api/chat.go":          "package api\n",
+		"api/chat_gen.go":      "package api\n",
+		"api/v1/orders.go":     "package v1\n",
+		"internal/store/db.go": "package store\n",
+	})
+	tests := []struct {
+		name string
+		init func(ix *Index)
+		want []string
+	}{
+		{
+			name: "defaults",
+			init: func(ix *Index) {},
+			want: []string{"api/chat.go", "api/chat_gen.go", "api/v1/orders.go", "internal/store/db.go", "legacy.go", "main.go"},
+		},
+		{
+			name: "build tags",
+			init: func(ix *Index) { ix.Tags = []string{"socketeer_extra"} },
+			want: []string{"api/chat.go", "api/chat_gen.go", "api/v1/orders.go", "extra.go", "internal/store/db.go", "main.go"},
+		},
+		{
+			name: "exclude file glob and directory",
+			init: func(ix *Index) { ix.Exclude = []string{"*_gen.go", "internal"} },
+			want: []string{"api/chat.go", "api/v1/orders.go", "legacy.go", "main.go"},
+		},
+		{
+			name: "include path glob",
+			init: func(ix *Index) { ix.Include = []string{"api/**"} },
+			want: []string{"api/chat.go", "api/chat_gen.go", "api/v1/orders.go"},
+		},
+		{
+			name: "include and exclude",
+			init: func(ix *Index) { ix.Include, ix.Exclude = []string{"api/**"}, []string{"api/v1"} },
+			want: []string{"api/chat.go", "api/chat_gen.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ix := NewIndex(dir)
+			tt.init(ix)
+			if got := scanned(t, ix, dir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scanned %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"go.work": `go 1.24
+
+use (
+	./api // the public API
+	"./worker"
+)
+`,
+		"tools.go":                  "package tools\n",
+		"api/go.mod":                "module example.com/api\n",
+		"api/chat.go":               "package api\n",
+		"api/plugin/go.mod":         "module example.com/plugin\n",
+		"api/plugin/plugin.go":      "package plugin\n",
+		"worker/go.mod":             "module example.com/worker\n",
+		"worker/jobs/jobs.go":       "package jobs\n",
+		"unused/go.mod":             "module example.com/unused\n",
+		"unused/unused.go":          "package unused\n",
+		"worker/vendor/dep/dep.go":  "package dep\n",
+		"worker/testdata/sample.go": "package sample\n",
+	})
+	ix := NewIndex(dir)
+	want := []string{"api/chat.go", "worker/jobs/jobs.go"}
+	if got := scanned(t, ix, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("scanned %q, want %q", got, want)
+	}
+	wantDirs := []string{filepath.Join(dir, "api"), filepath.Join(dir, "worker")}
+	if got := ix.Dirs(); !reflect.DeepEqual(got, wantDirs) {
+		t.Errorf("Dirs() = %q, want %q", got, wantDirs)
+	}
+
+	// Without a go.work file nested modules are scanned like any directory
+	if err := os.Remove(filepath.Join(dir, "go.work")); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"api/chat.go", "api/plugin/plugin.go", "tools.go", "unused/unused.go", "worker/jobs/jobs.go"}
+	if got := scanned(t, NewIndex(dir), dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("scanned without go.work %q, want %q", got, want)
+	}
+}
+
+func TestWorkspaceUses(t *testing.T) {
+	tests := map[string][]string{
+		"go 1.24\n\nuse ./api\n":                                {"./api"},
+		"use (\n\t./api\n\t\"./worker\" // jobs\n)\nuse ../x\n": {"./api", "./worker", "../x"},
+		"// use ./commented\ngo 1.24\n":                         nil,
+		"use (\n)\n":                                            nil,
+	}
+	for work, want := range tests {
+		if got := workspaceUses(work); !reflect.DeepEqual(got, want) {
+			t.Errorf("workspaceUses(%q) = %q, want %q", work, got, want)
+		}
+	}
+}
+
+func TestMatchAny(t *testing.T) {
+	tests := []struct {
+		globs []string
+		rel   string
+		want  bool
+	}{
+		{[]string{"*_gen.go"}, "api/chat_gen.go", true},
+		{[]string{"*_gen.go"}, "api/chat.go", false},
+		{[]string{"mocks"}, "internal/mocks/store.go", true},
+		{[]string{"mocks"}, "internal/mocksy/store.go", false},
+		{[]string{"api/*.go"}, "api/chat.go", true},
+		{[]string{"api/*.go"}, "api/v1/orders.go", false},
+		{[]string{"api/**"}, "api/v1/orders.go", true},
+		{[]string{"**/v1/*.go"}, "services/api/v1/orders.go", true},
+		{[]string{"./api/v1/"}, "api/v1/orders.go", true},
+		{[]string{"api/v1"}, "api/v2/orders.go", false},
+		{[]string{"docs", "*.pb.go"}, "api/chat.pb.go", true},
+		{nil, "main.go", false},
+	}
+	for _, tt := range tests {
+		if got := matchAny(tt.globs, tt.rel); got != tt.want {
+			t.Errorf("matchAny(%q, %q) = %v, want %v", tt.globs, tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestSkippedDir(t *testing.T) {
+	for name, want := range map[string]bool{
+		"vendor": true, "testdata": true, ".git": true, "_old": true,
+		".": false, "..": false, "api": false, "vendors": false,
+	} {
+		if got := skippedDir(name); got != want {
+			t.Errorf("skippedDir(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,467 @@
+package parser
+
+import (
+	"encoding/json"
+	"go/ast"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/muratmirgun/socketeer/internal/spec"
+)
+
+// maxSchemaDepth limits how deeply nested struct types are expanded, which
+// also stops recursive types.
+const maxSchemaDepth = 8
+
+// structField is a JSON field of a struct, its Go type and documentation.
+type structField struct {
+	name       string
+	typ        ast.Expr
+	tag        reflect.StructTag
+	doc        string
+	deprecated bool
+	example    bool // has an Example: comment
+	embedded   bool // fields are promoted to the parent: an embedded struct, or a field marked ,inline
+	omitempty  bool
+	asString   bool // the ,string option: the value is encoded inside a JSON string
+}
+
+func newStructField(name string, f *ast.Field) structField {
+	sf := structField{name: name, typ: f.Type}
+	if f.Tag != nil {
+		if tag, err := strconv.Unquote(f.Tag.Value); err == nil {
+			sf.tag = reflect.StructTag(tag)
+		}
+		_, opts, _ := strings.Cut(sf.tag.Get("json"), ",")
+		sf.omitempty = hasOption(opts, "omitempty")
+		sf.asString = hasOption(opts, "string")
+	}
+	sf.doc, sf.deprecated = fieldDoc(f.Doc)
+	if sf.doc == "" {
+		sf.doc, sf.deprecated = fieldDoc(f.Comment)
+	}
+	for _, cg := range []*ast.CommentGroup{f.Doc, f.Comment} {
+		if cg != nil && strings.Contains(cg.Text(), "Example:") {
+			sf.example = true
+		}
+	}
+	return sf
+}
+
+// fieldDoc returns a field's doc comment as one paragraph, without Example:
+// lines, and whether it has a Deprecated: paragraph.
+func fieldDoc(cg *ast.CommentGroup) (string, bool) {
+	if cg == nil {
+		return "", false
+	}
+	var lines []string
+	deprecated := false
+	for _, line := range strings.Split(cg.Text(), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "", strings.HasPrefix(line, "Example:"):
+			continue
+		case strings.HasPrefix(line, "Deprecated:"):
+			deprecated = true
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, " "), deprecated
+}
+
+// structSchema returns the schema of a struct payload, looking the struct up
+// like structPayload does.
+func structSchema(structMap map[string]StructInfo, structName string) *spec.Schema {
+	s, ok := lookupStruct(structMap, structName)
+	if !ok {
+		return nil
+	}
+	return s.typeSchema(structMap, 0)
+}
+
+func (s StructInfo) schema(structMap map[string]StructInfo, depth int) *spec.Schema {
+	schema := &spec.Schema{Type: "object"}
+	s.addProperties(schema, structMap, depth)
+	return schema
+}
+
+// addProperties adds the fields of s to an object schema. Fields of embedded
+// structs are promoted unless the struct declares a field of the same name,
+// as encoding/json does.
+func (s StructInfo) addProperties(schema *spec.Schema, structMap map[string]StructInfo, depth int) {
+	for _, f := range s.fields {
+		if f.embedded {
+			inner, ok := typeInfo(structMap, f.typ)
+			if !ok || !inner.isStruct() || depth >= maxSchemaDepth {
+				continue
+			}
+			promoted := &spec.Schema{}
+			inner.addProperties(promoted, structMap, depth+1)
+			for _, p := range promoted.Properties {
+				if s.declares(p.Name) || schema.Properties.Get(p.Name) != nil {
+					continue
+				}
+				schema.Properties = append(schema.Properties, p)
+				if slices.Contains(promoted.Required, p.Name) {
+					schema.Required = append(schema.Required, p.Name)
+				}
+			}
+			continue
+		}
+		fs := typeSchema(structMap, f.typ, depth+1)
+		if f.doc != "" {
+			fs.Description = f.doc
+		}
+		fs.Deprecated = f.deprecated
+		if applyTags(fs, f.tag) {
+			schema.Required = append(schema.Required, f.name)
+		}
+		if f.asString && (fs.Type == "integer" || fs.Type == "number" || fs.Type == "boolean") {
+			fs.Type = "string"
+		}
+		schema.Properties = append(schema.Properties, spec.Property{Name: f.name, Schema: fs})
+	}
+}
+
+// declares reports whether s has a field with the JSON name, not counting embedded structs.
+func (s StructInfo) declares(name string) bool {
+	return slices.ContainsFunc(s.fields, func(f structField) bool { return !f.embedded && f.name == name })
+}
+
+func (s StructInfo) isStruct() bool {
+	return s.Fields != nil
+}
+
+// applyTags adds the constraints of a field's validate, enums, format and
+// default tags to its schema and reports whether the field is required.
+func applyTags(s *spec.Schema, tag reflect.StructTag) bool {
+	required := false
+	for _, rule := range strings.Split(tag.Get("validate"), ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "dive":
+			// the remaining rules apply to the items of a slice or map
+			return required
+		case "required":
+			required = true
+		case "min", "gte":
+			setBound(s, value, true)
+		case "max", "lte":
+			setBound(s, value, false)
+		case "len":
+			setBound(s, value, true)
+			setBound(s, value, false)
+		case "oneof":
+			s.Enum = nil
+			for _, v := range strings.Fields(value) {
+				s.Enum = append(s.Enum, typedValue(s.Type, strings.Trim(v, "'")))
+			}
+		case "email":
+			s.Format = "email"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "url", "uri":
+			s.Format = "uri"
+		case "datetime":
+			s.Format = "date-time"
+		}
+	}
+	if enums, ok := tag.Lookup("enums"); ok {
+		s.Enum = nil
+		for _, v := range splitList(enums) {
+			s.Enum = append(s.Enum, typedValue(s.Type, v))
+		}
+	}
+	if format, ok := tag.Lookup("format"); ok {
+		s.Format = format
+	}
+	if def, ok := tag.Lookup("default"); ok {
+		s.Default = typedValue(s.Type, def)
+	}
+	if len(s.EnumValues) > 0 {
+		// oneof and enums narrow the typed constants of the field's type
+		s.EnumValues = slices.DeleteFunc(slices.Clone(s.EnumValues), func(v spec.EnumValue) bool {
+			return !slices.ContainsFunc(s.Enum, func(e interface{}) bool { return reflect.DeepEqual(e, v.Value) })
+		})
+	}
+	return required
+}
+
+// setBound sets the lower or upper bound matching the schema's type: the
+// length of strings, the number of items of arrays or the value of numbers.
+func setBound(s *spec.Schema, value string, lower bool) {
+	switch s.Type {
+	case "string", "array":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return
+		}
+		switch {
+		case s.Type == "string" && lower:
+			s.MinLength = &n
+		case s.Type == "string":
+			s.MaxLength = &n
+		case lower:
+			s.MinItems = &n
+		default:
+			s.MaxItems = &n
+		}
+	case "integer", "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return
+		}
+		if lower {
+			s.Minimum = &n
+		} else {
+			s.Maximum = &n
+		}
+	}
+}
+
+// typedValue converts a tag value to the schema's type, keeping it a string if it does not parse.
+func typedValue(typ, v string) interface{} {
+	switch typ {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// typeSchema maps a Go type to a schema. Types it cannot resolve accept any value.
+func typeSchema(structMap map[string]StructInfo, expr ast.Expr, depth int) *spec.Schema {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return typeSchema(structMap, t.X, depth)
+	case *ast.ArrayType:
+		if id, ok := t.Elt.(*ast.Ident); ok && id.Name == "byte" {
+			return &spec.Schema{Type: "string"} // base64 encoded
+		}
+		return &spec.Schema{Type: "array", Items: typeSchema(structMap, t.Elt, depth)}
+	case *ast.MapType:
+		return &spec.Schema{Type: "object"}
+	case *ast.Ident:
+		if typ := basicType(t.Name); typ != "" {
+			return &spec.Schema{Type: typ}
+		}
+		return namedSchema(structMap, t.Name, depth)
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok {
+			return namedSchema(structMap, pkg.Name+"."+t.Sel.Name, depth)
+		}
+	}
+	return &spec.Schema{}
+}
+
+func namedSchema(structMap map[string]StructInfo, name string, depth int) *spec.Schema {
+	s, ok := structMap[name]
+	if !ok {
+		if known, ok := wellKnownTypes[name]; ok {
+			return &known
+		}
+		return &spec.Schema{}
+	}
+	return s.typeSchema(structMap, depth)
+}
+
+// typeSchema returns the schema of the named type s describes. A
+// //socketeer:schema directive wins; types with a MarshalText method are
+// strings and those with a MarshalJSON method accept any value.
+func (s StructInfo) typeSchema(structMap map[string]StructInfo, depth int) *spec.Schema {
+	switch {
+	case s.override != nil:
+		override := *s.override
+		return &override
+	case s.marshal == "text":
+		return &spec.Schema{Type: "string"}
+	case s.marshal == "json", depth > maxSchemaDepth:
+		return &spec.Schema{}
+	case s.basic != "":
+		schema := &spec.Schema{Type: basicType(s.basic)}
+		for _, v := range s.enum {
+			schema.Enum = append(schema.Enum, v.Value)
+		}
+		schema.EnumValues = s.enum
+		return schema
+	case s.underlying != nil:
+		return typeSchema(structMap, s.underlying, depth+1)
+	}
+	return s.schema(structMap, depth)
+}
+
+// typeInfo returns the index entry of a named type expression such as Author, *Author or model.Author.
+func typeInfo(structMap map[string]StructInfo, expr ast.Expr) (StructInfo, bool) {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return typeInfo(structMap, t.X)
+	case *ast.Ident:
+		s, ok := structMap[t.Name]
+		return s, ok
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok {
+			s, ok := structMap[pkg.Name+"."+t.Sel.Name]
+			return s, ok
+		}
+	}
+	return StructInfo{}, false
+}
+
+// encoded returns the example fields of s as encoding/json writes them:
+// fields of embedded structs are promoted, ,string values are quoted and
+// omitempty fields with an empty Example: comment are left out. Fields without an Example: comment
+// get the first value of their enum, or an example built from their schema
+// when their type is a struct or encodes differently from its Go shape.
+func (s StructInfo) encoded(structMap map[string]StructInfo, depth int) StructInfo {
+	out := StructInfo{Fields: map[string]interface{}{}}
+	add := func(name string, v interface{}) {
+		if _, dup := out.Fields[name]; !dup {
+			out.Fields[name] = v
+			out.Order = append(out.Order, name)
+		}
+	}
+	for _, f := range s.fields {
+		info, named := typeInfo(structMap, f.typ)
+		if f.embedded {
+			if named && info.isStruct() && depth < maxSchemaDepth {
+				inner := info.encoded(structMap, depth+1)
+				for _, name := range inner.Order {
+					if !s.declares(name) {
+						add(name, inner.Fields[name])
+					}
+				}
+			}
+			continue
+		}
+		v, ok := s.Fields[f.name]
+		switch {
+		case f.example:
+		case len(info.enum) > 0:
+			v, ok = info.enum[0].Value, true
+		case named && info.isStruct() && info.override == nil && info.marshal == "" && depth < maxSchemaDepth:
+			if b, err := info.encoded(structMap, depth+1).JSON(); err == nil {
+				v, ok = json.RawMessage(b), true
+			}
+		case !ok, named && (info.override != nil || info.marshal != ""), isWellKnown(f.typ):
+			v, ok = schemaExample(typeSchema(structMap, f.typ, depth+1))
+		}
+		if !ok {
+			continue
+		}
+		// An empty example of an omitempty field is left out before quoting,
+		// as encoding/json does; placeholders are kept to show the field
+		if f.omitempty && f.example && isEmpty(v) {
+			continue
+		}
+		if f.asString {
+			v = quoted(v)
+		}
+		add(f.name, v)
+	}
+	return out
+}
+
+func isWellKnown(expr ast.Expr) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if sel, ok := expr.(*ast.SelectorExpr); ok {
+		if pkg, ok := sel.X.(*ast.Ident); ok {
+			_, known := wellKnownTypes[pkg.Name+"."+sel.Sel.Name]
+			return known
+		}
+	}
+	return false
+}
+
+// schemaExample returns a placeholder value matching a schema, like the
+// values fileStructs writes for basic types.
+func schemaExample(s *spec.Schema) (interface{}, bool) {
+	switch {
+	case s == nil:
+		return nil, false
+	case s.Default != nil:
+		return s.Default, true
+	case len(s.Enum) > 0:
+		return s.Enum[0], true
+	}
+	switch s.Type {
+	case "string":
+		return "string", true
+	case "integer", "number":
+		return 0, true
+	case "boolean":
+		return false, true
+	case "array":
+		return []interface{}{}, true
+	case "object":
+		obj := map[string]interface{}{}
+		for _, p := range s.Properties {
+			if v, ok := schemaExample(p.Schema); ok {
+				obj[p.Name] = v
+			}
+		}
+		return obj, true
+	}
+	return nil, false
+}
+
+// quoted encodes a number, boolean or string inside a JSON string, as the ,string option does.
+func quoted(v interface{}) interface{} {
+	switch v.(type) {
+	case string, bool, int, int64, float64:
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return v
+}
+
+// isEmpty reports whether encoding/json treats a value as empty for omitempty.
+func isEmpty(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case string:
+		return val == ""
+	case bool:
+		return !val
+	case int:
+		return val == 0
+	case int64:
+		return val == 0
+	case float64:
+		return val == 0
+	case []interface{}:
+		return len(val) == 0
+	case map[string]interface{}:
+		return len(val) == 0
+	}
+	return false
+}
+
+// basicType returns the JSON Schema type of a predeclared Go type, or "".
+func basicType(name string) string {
+	switch name {
+	case "string":
+		return "string"
+	case "bool":
+		return "boolean"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "rune", "byte":
+		return "integer"
+	case "float32", "float64":
+		return "number"
+	}
+	return ""
+}
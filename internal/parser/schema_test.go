@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestStructPayloadTagOptions(t *testing.T) {
+	const src = `package dto
+
+type Counter struct {
+	Count   int    ` + "`json:\"count,omitempty,string\"`" + `
+	ID      int64  ` + "`json:\"id,string\"`" + `
+	Enabled bool   ` + "`json:\"enabled,string,omitempty\"`" + `
+	Label   string ` + "`json:\"label,omitempty\"`" + `
+	Skipped string ` + "`json:\"-\"`" + `
+}
+`
+	file, err := parser.ParseFile(token.NewFileSet(), "dto.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"count":"0","id":"0","enabled":"false","label":"string"}`
+	got, ok := structPayload(fileStructs(file), "Counter")
+	if !ok || got != want {
+		t.Errorf("structPayload(Counter) = %v, %v, want %s", got, ok, want)
+	}
+}
+
+func TestStructPayloadOmitEmpty(t *testing.T) {
+	const src = `package dto
+
+type Order struct {
+	Name   string   ` + "`json:\"name\"`" + `
+	Count  int      ` + "`json:\"count,omitempty\"`" + `
+	Active bool     ` + "`json:\"active,omitempty\"`" + `
+	Items  []string ` + "`json:\"items,omitempty\"`" + `
+	// Example: 0
+	Retries int ` + "`json:\"retries,omitempty,string\"`" + `
+	// Example: ""
+	Note string ` + "`json:\"note,omitempty\"`" + `
+	// Example: []
+	Tags []string ` + "`json:\"tags,omitempty\"`" + `
+	// Example: 3
+	Limit int ` + "`json:\"limit,omitempty,string\"`" + `
+}
+`
+	file, err := parser.ParseFile(token.NewFileSet(), "dto.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Placeholders are kept to document the fields; explicitly empty
+	// examples are omitted as encoding/json would, before ,string quoting
+	const want = `{"name":"string","count":0,"active":false,"items":[],"limit":"3"}`
+	got, ok := structPayload(fileStructs(file), "Order")
+	if !ok || got != want {
+		t.Errorf("structPayload(Order) = %v, %v, want %s", got, ok, want)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import "net/http"
+
+// Money is encoded as a decimal string.
+//
+//socketeer:schema file:schemas/money.yaml
+type Money int64
+
+// Labels has an empty default.
+//
+//socketeer:schema {"type": "object", "default": {}, "properties": {"tags": {"type": "array", "default": []}}}
+type Labels map[string]string
+
+// Status of an order.
+type Status string
+
+const (
+	Pending Status = "pending" // Not paid yet
+	Paid    Status = "paid"
+)
+
+type Empty struct{}
+
+type Order struct {
+	ID     int      `json:"id,string"`
+	Tags   []string `json:"tags"`
+	Status Status   `json:"status"`
+	Price  Money    `json:"price"`
+	Labels Labels   `json:"labels"`
+	Empty  Empty    `json:"empty"`
+	Note   string   `json:"note,omitempty"`
+}
+
+// @WebSocket orders
+// @URL /ws/orders
+// @Message order
+// @Send
+// @Payload Order
+// @Example none {"tags": [], "labels": {}}
+// @Message money
+// @Receive
+// @Payload Money
+func Handler(w http.ResponseWriter, r *http.Request) {}
@@ -0,0 +1,22 @@
+package chat
+
+// @WebSocket Room
+// @URL /ws/chat
+// @Message join_room
+// @Send
+//
+//socketeer:ignore message-naming
+func serveRoom() {}
+
+// @Socket Room
+// @Message leave_room
+// @Send
+//
+//socketeer:ignore send-reply,message-description
+func leaveRoom() {}
+
+// @WebSocket Lobby
+// @URL /ws/lobby
+// @Message enter_lobby
+// @Send
+func serveLobby() {}
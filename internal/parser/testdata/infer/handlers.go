@@ -0,0 +1,63 @@
+package chat
+
+const TypeLeave = "leave"
+
+// readWriteJSON decodes with ReadJSON and dispatches on the type field.
+func readWriteJSON(conn *websocket.Conn) {
+	var msg Envelope
+	for {
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		switch msg.Type {
+		case "join":
+			var join JoinRequest
+			json.Unmarshal(msg.Data, &join)
+		case TypeLeave, "quit":
+		}
+		conn.WriteJSON(Welcome{Type: "welcome", Room: msg.Room})
+		conn.WriteJSON(gin.H{"type": "error", "code": 4, "fatal": true, "detail": msg.Room})
+	}
+}
+
+// unmarshalTyped decodes with json.Unmarshal and dispatches in another function.
+func unmarshalTyped(data []byte, conn *websocket.Conn) {
+	env := &Envelope{}
+	if err := json.Unmarshal(data, env); err != nil {
+		return
+	}
+	dispatch(conn, env)
+}
+
+func dispatch(conn *websocket.Conn, e *Envelope) {
+	switch e.Type {
+	case "ping":
+		conn.WriteJSON(&Pong{Type: "pong"})
+	}
+}
+
+// wsjsonHandler uses nhooyr.io/websocket's wsjson helpers.
+func wsjsonHandler(ctx context.Context, c *websocket.Conn) {
+	var m Command
+	wsjson.Read(ctx, c, &m)
+	switch (m.Action) {
+	case "subscribe":
+		sub := new(Subscription)
+		json.NewDecoder(r).Decode(sub)
+	}
+	wsjson.Write(ctx, c, Ack{Action: "ack"})
+}
+
+// opaque writes and switches on values whose type cannot be determined.
+func opaque(conn *websocket.Conn, reply interface{}, kind string) {
+	switch kind {
+	case "hidden":
+	}
+	var other Envelope
+	switch other.Type {
+	case "not-decoded":
+	}
+	conn.WriteJSON(reply)
+	conn.WriteJSON(Notice{Text: "no discriminator"})
+	conn.WriteJSON(Event{Type: dynamicType()})
+}
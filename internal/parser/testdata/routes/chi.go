@@ -0,0 +1,13 @@
+package main
+
+func main() {
+	r := chi.NewRouter()
+	r.Get("/ws/feed", feedHandler)
+	r.Route("/api", func(r chi.Router) {
+		r.Route("/v2", func(r chi.Router) {
+			r.Get("/ws/{room}", roomHandler)
+		})
+		r.Method("GET", "/ws/method", http.HandlerFunc(methodHandler))
+	})
+	r.Post("/ws/feed", postFeed)
+}
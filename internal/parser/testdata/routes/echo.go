@@ -0,0 +1,9 @@
+package main
+
+func main() {
+	e := echo.New()
+	e.GET("/ws/notify/:user", notify)
+	admin := e.Group("/admin")
+	admin.GET("/ws/audit", audit.Serve)
+	e.Match([]string{"GET", "POST"}, "/ws/match", matchHandler)
+}
@@ -0,0 +1,10 @@
+package main
+
+func main() {
+	r := gin.Default()
+	r.GET("/ws/chat", chatHandler)
+	r.GET("/ws/rooms/:room", h.Room)
+	r.Any("/ws/any", gin.WrapF(anyHandler))
+	r.POST("/api/messages", postMessage)
+	r.Static("/assets", "./assets")
+}
@@ -0,0 +1,8 @@
+package main
+
+func routes(r *gin.Engine) {
+	api := r.Group("/api")
+	v1 := api.Group("/v1")
+	v1.GET("/ws/orders/:id", orders.Stream)
+	api.GET("/ws/*path", wildcard)
+}
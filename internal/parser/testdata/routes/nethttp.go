@@ -0,0 +1,10 @@
+package main
+
+func main() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/echo", echoHandler)
+	mux.Handle("GET /ws/rooms/{room}/{rest...}", serveWs(hub))
+	http.Handle("/ws/typed", &TypedHandler{})
+	http.HandleFunc("/ws/exact/{$}", exact)
+	http.HandleFunc(pattern, dynamic)
+}
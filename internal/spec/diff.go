@@ -0,0 +1,195 @@
+package spec
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Kinds of Change.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is a difference between two versions of a spec.
+type Change struct {
+	Path    string // what changed, e.g. sockets[chat].messages[send join].schema.properties[room]
+	Kind    string // Added, Removed or Changed
+	Message string
+	// Breaking marks changes that can break clients written against the old
+	// version, e.g. a removed socket or a new required field in a message they send.
+	Breaking bool
+}
+
+func (c Change) String() string {
+	if c.Breaking {
+		return fmt.Sprintf("%s: %s (breaking)", c.Path, c.Message)
+	}
+	return fmt.Sprintf("%s: %s", c.Path, c.Message)
+}
+
+// Diff returns the changes from the old to the new version of a spec, from
+// the point of view of a client: sockets, servers, connection parameters,
+// messages and their payload schemas. Descriptions and examples are ignored.
+// Sockets and servers are matched by name, messages by direction and type.
+func Diff(from, to *Spec) []Change {
+	var changes []Change
+	add := func(path, kind string, breaking bool, format string, args ...interface{}) {
+		changes = append(changes, Change{Path: path, Kind: kind, Message: fmt.Sprintf(format, args...), Breaking: breaking})
+	}
+
+	if from.Info.Version != to.Info.Version {
+		add("info.version", Changed, false, "version %q is now %q", from.Info.Version, to.Info.Version)
+	}
+
+	for _, srv := range from.Servers {
+		path := fmt.Sprintf("servers[%s]", srv.Name)
+		if n := to.Server(srv.Name); n == nil {
+			add(path, Removed, true, "server removed")
+		} else if n.URL != srv.URL {
+			add(path+".url", Changed, true, "URL %q is now %q", srv.URL, n.URL)
+		}
+	}
+	for _, srv := range to.Servers {
+		if from.Server(srv.Name) == nil {
+			add(fmt.Sprintf("servers[%s]", srv.Name), Added, false, "server added")
+		}
+	}
+
+	for _, socket := range from.Sockets {
+		path := fmt.Sprintf("sockets[%s]", socket.Name)
+		n := findSocket(to, socket.Name)
+		if n == nil {
+			add(path, Removed, true, "socket removed")
+			continue
+		}
+		if n.URL != socket.URL {
+			add(path+".url", Changed, true, "URL %q is now %q", socket.URL, n.URL)
+		}
+		changes = append(changes, diffParams(path, socket.ConnectionParams, n.ConnectionParams)...)
+		changes = append(changes, diffMessages(path, socket.Messages, n.Messages)...)
+	}
+	for _, socket := range to.Sockets {
+		if findSocket(from, socket.Name) == nil {
+			add(fmt.Sprintf("sockets[%s]", socket.Name), Added, false, "socket added")
+		}
+	}
+	return changes
+}
+
+func findSocket(s *Spec, name string) *Socket {
+	for i := range s.Sockets {
+		if s.Sockets[i].Name == name {
+			return &s.Sockets[i]
+		}
+	}
+	return nil
+}
+
+// diffParams compares connection parameters: a new or newly required parameter breaks clients.
+func diffParams(path string, from, to []ConnectionParam) []Change {
+	var changes []Change
+	find := func(params []ConnectionParam, p ConnectionParam) *ConnectionParam {
+		for i := range params {
+			if params[i].Name == p.Name && params[i].In == p.In {
+				return &params[i]
+			}
+		}
+		return nil
+	}
+	for _, p := range from {
+		pp := fmt.Sprintf("%s.connectionParams[%s]", path, p.Name)
+		n := find(to, p)
+		switch {
+		case n == nil:
+			changes = append(changes, Change{Path: pp, Kind: Removed, Message: fmt.Sprintf("%s parameter removed", p.In)})
+		case n.Required && !p.Required:
+			changes = append(changes, Change{Path: pp, Kind: Changed, Message: "parameter is now required", Breaking: true})
+		case n.Type != p.Type:
+			changes = append(changes, Change{Path: pp, Kind: Changed, Message: fmt.Sprintf("type %q is now %q", p.Type, n.Type), Breaking: true})
+		}
+	}
+	for _, p := range to {
+		if find(from, p) == nil {
+			changes = append(changes, Change{
+				Path: fmt.Sprintf("%s.connectionParams[%s]", path, p.Name), Kind: Added,
+				Message: fmt.Sprintf("%s parameter added", p.In), Breaking: p.Required,
+			})
+		}
+	}
+	return changes
+}
+
+func diffMessages(path string, from, to []Message) []Change {
+	var changes []Change
+	find := func(msgs []Message, m Message) *Message {
+		for i := range msgs {
+			if msgs[i].Type == m.Type && msgs[i].Direction == m.Direction {
+				return &msgs[i]
+			}
+		}
+		return nil
+	}
+	for _, m := range from {
+		mp := fmt.Sprintf("%s.messages[%s %s]", path, m.Direction, m.Type)
+		n := find(to, m)
+		if n == nil {
+			changes = append(changes, Change{Path: mp, Kind: Removed, Message: "message removed", Breaking: true})
+			continue
+		}
+		if n.Deprecated && !m.Deprecated {
+			changes = append(changes, Change{Path: mp, Kind: Changed, Message: "message deprecated"})
+		}
+		changes = append(changes, diffSchema(mp+".schema", m.Direction, m.Schema, n.Schema)...)
+	}
+	for _, m := range to {
+		if find(from, m) == nil {
+			changes = append(changes, Change{Path: fmt.Sprintf("%s.messages[%s %s]", path, m.Direction, m.Type), Kind: Added, Message: "message added"})
+		}
+	}
+	return changes
+}
+
+// diffSchema compares payload schemas of a message. Clients must produce what
+// they send and understand what they receive, so new constraints break sent
+// messages and removed fields or new values break received ones.
+func diffSchema(path, direction string, from, to *Schema) []Change {
+	if from == nil || to == nil {
+		return nil
+	}
+	send := direction == "send"
+	var changes []Change
+	if from.Type != to.Type {
+		return append(changes, Change{Path: path, Kind: Changed, Message: fmt.Sprintf("type %q is now %q", from.Type, to.Type), Breaking: true})
+	}
+	for _, v := range from.Enum {
+		if !slices.ContainsFunc(to.Enum, func(w interface{}) bool { return sameValue(v, w) }) {
+			changes = append(changes, Change{Path: path + ".enum", Kind: Removed, Message: fmt.Sprintf("value %v removed", v), Breaking: send})
+		}
+	}
+	for _, v := range to.Enum {
+		if len(from.Enum) > 0 && !slices.ContainsFunc(from.Enum, func(w interface{}) bool { return sameValue(v, w) }) {
+			changes = append(changes, Change{Path: path + ".enum", Kind: Added, Message: fmt.Sprintf("value %v added", v), Breaking: !send})
+		}
+	}
+	for _, p := range from.Properties {
+		pp := fmt.Sprintf("%s.properties[%s]", path, p.Name)
+		n := to.Properties.Get(p.Name)
+		if n == nil {
+			changes = append(changes, Change{Path: pp, Kind: Removed, Message: "field removed", Breaking: !send})
+			continue
+		}
+		if send && slices.Contains(to.Required, p.Name) && !slices.Contains(from.Required, p.Name) {
+			changes = append(changes, Change{Path: pp, Kind: Changed, Message: "field is now required", Breaking: true})
+		}
+		changes = append(changes, diffSchema(pp, direction, p.Schema, n)...)
+	}
+	for _, p := range to.Properties {
+		if from.Properties.Get(p.Name) == nil {
+			required := slices.Contains(to.Required, p.Name)
+			changes = append(changes, Change{Path: fmt.Sprintf("%s.properties[%s]", path, p.Name), Kind: Added, Message: "field added", Breaking: send && required})
+		}
+	}
+	return append(changes, diffSchema(path+".items", direction, from.Items, to.Items)...)
+}
@@ -0,0 +1,163 @@
+package spec
+
+import (
+	"reflect"
+	"testing"
+)
+
+// diffBase is a spec with a sent and a received message, each with a schema.
+func diffBase() *Spec {
+	return &Spec{
+		Info:    Info{Version: "1.0.0"},
+		Servers: []Server{{Name: "production", URL: "wss://api.example.com"}},
+		Sockets: []Socket{{
+			Name: "Room",
+			URL:  "/ws/chat",
+			ConnectionParams: []ConnectionParam{
+				{Name: "token", In: "query", Type: "string"},
+			},
+			Messages: []Message{
+				{Type: "join", Direction: "send", Schema: &Schema{
+					Type: "object",
+					Properties: Properties{
+						{Name: "room", Schema: &Schema{Type: "string"}},
+						{Name: "level", Schema: &Schema{Type: "integer", Enum: []interface{}{1, 2, 3}}},
+					},
+					Required: []string{"room"},
+				}},
+				{Type: "event", Direction: "receive", Schema: &Schema{
+					Type: "object",
+					Properties: Properties{
+						{Name: "kind", Schema: &Schema{Type: "string", Enum: []interface{}{"a", "b"}}},
+						{Name: "text", Schema: &Schema{Type: "string"}},
+					},
+				}},
+			},
+		}},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	const join, event = "sockets[Room].messages[send join].schema", "sockets[Room].messages[receive event].schema"
+	tests := []struct {
+		name   string
+		change func(s *Spec)
+		want   []Change
+	}{
+		{"unchanged", func(s *Spec) {}, nil},
+		{
+			"removed socket",
+			func(s *Spec) { s.Sockets = nil },
+			[]Change{{Path: "sockets[Room]", Kind: Removed, Message: "socket removed", Breaking: true}},
+		},
+		{
+			"added socket",
+			func(s *Spec) { s.Sockets = append(s.Sockets, Socket{Name: "Lobby", URL: "/ws/lobby"}) },
+			[]Change{{Path: "sockets[Lobby]", Kind: Added, Message: "socket added"}},
+		},
+		{
+			"changed socket URL",
+			func(s *Spec) { s.Sockets[0].URL = "/ws/rooms" },
+			[]Change{{Path: "sockets[Room].url", Kind: Changed, Message: `URL "/ws/chat" is now "/ws/rooms"`, Breaking: true}},
+		},
+		{
+			"removed server",
+			func(s *Spec) { s.Servers = nil },
+			[]Change{{Path: "servers[production]", Kind: Removed, Message: "server removed", Breaking: true}},
+		},
+		{
+			"removed message",
+			func(s *Spec) { s.Sockets[0].Messages = s.Sockets[0].Messages[:1] },
+			[]Change{{Path: "sockets[Room].messages[receive event]", Kind: Removed, Message: "message removed", Breaking: true}},
+		},
+		{
+			"deprecated message",
+			func(s *Spec) { s.Sockets[0].Messages[0].Deprecated = true },
+			[]Change{{Path: "sockets[Room].messages[send join]", Kind: Changed, Message: "message deprecated"}},
+		},
+		{
+			"new required field in a sent message",
+			func(s *Spec) {
+				schema := s.Sockets[0].Messages[0].Schema
+				schema.Properties = append(schema.Properties, Property{Name: "nick", Schema: &Schema{Type: "string"}})
+				schema.Required = append(schema.Required, "nick")
+			},
+			[]Change{{Path: join + ".properties[nick]", Kind: Added, Message: "field added", Breaking: true}},
+		},
+		{
+			"new optional field in a sent message",
+			func(s *Spec) {
+				schema := s.Sockets[0].Messages[0].Schema
+				schema.Properties = append(schema.Properties, Property{Name: "nick", Schema: &Schema{Type: "string"}})
+			},
+			[]Change{{Path: join + ".properties[nick]", Kind: Added, Message: "field added"}},
+		},
+		{
+			"optional field of a sent message now required",
+			func(s *Spec) { s.Sockets[0].Messages[0].Schema.Required = []string{"room", "level"} },
+			[]Change{{Path: join + ".properties[level]", Kind: Changed, Message: "field is now required", Breaking: true}},
+		},
+		{
+			"field removed from a received message",
+			func(s *Spec) {
+				s.Sockets[0].Messages[1].Schema.Properties = s.Sockets[0].Messages[1].Schema.Properties[:1]
+			},
+			[]Change{{Path: event + ".properties[text]", Kind: Removed, Message: "field removed", Breaking: true}},
+		},
+		{
+			"field removed from a sent message",
+			func(s *Spec) {
+				s.Sockets[0].Messages[0].Schema.Properties = s.Sockets[0].Messages[0].Schema.Properties[:1]
+			},
+			[]Change{{Path: join + ".properties[level]", Kind: Removed, Message: "field removed"}},
+		},
+		{
+			"narrowed enum of a sent message",
+			func(s *Spec) { s.Sockets[0].Messages[0].Schema.Properties[1].Schema.Enum = []interface{}{1, 2} },
+			[]Change{{Path: join + ".properties[level].enum", Kind: Removed, Message: "value 3 removed", Breaking: true}},
+		},
+		{
+			"widened enum of a received message",
+			func(s *Spec) {
+				s.Sockets[0].Messages[1].Schema.Properties[0].Schema.Enum = []interface{}{"a", "b", "c"}
+			},
+			[]Change{{Path: event + ".properties[kind].enum", Kind: Added, Message: "value c added", Breaking: true}},
+		},
+		{
+			"widened enum of a sent message",
+			func(s *Spec) { s.Sockets[0].Messages[0].Schema.Properties[1].Schema.Enum = []interface{}{1, 2, 3, 4} },
+			[]Change{{Path: join + ".properties[level].enum", Kind: Added, Message: "value 4 added"}},
+		},
+		{
+			"field type change",
+			func(s *Spec) { s.Sockets[0].Messages[1].Schema.Properties[1].Schema = &Schema{Type: "integer"} },
+			[]Change{{Path: event + ".properties[text]", Kind: Changed, Message: `type "string" is now "integer"`, Breaking: true}},
+		},
+		{
+			"connection parameter now required",
+			func(s *Spec) { s.Sockets[0].ConnectionParams[0].Required = true },
+			[]Change{{Path: "sockets[Room].connectionParams[token]", Kind: Changed, Message: "parameter is now required", Breaking: true}},
+		},
+		{
+			"new required connection parameter",
+			func(s *Spec) {
+				s.Sockets[0].ConnectionParams = append(s.Sockets[0].ConnectionParams, ConnectionParam{Name: "X-Tenant", In: "header", Type: "string", Required: true})
+			},
+			[]Change{{Path: "sockets[Room].connectionParams[X-Tenant]", Kind: Added, Message: "header parameter added", Breaking: true}},
+		},
+		{
+			"connection parameter type change",
+			func(s *Spec) { s.Sockets[0].ConnectionParams[0].Type = "integer" },
+			[]Change{{Path: "sockets[Room].connectionParams[token]", Kind: Changed, Message: `type "string" is now "integer"`, Breaking: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to := diffBase()
+			tt.change(to)
+			if got := Diff(diffBase(), to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec file formats.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// FileFormat returns the format of a spec file by its extension: JSON for .json, YAML otherwise.
+func FileFormat(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// Load reads and decodes a spec file, YAML or JSON.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data)
+}
+
+// Decode decodes a spec from YAML or JSON, which is valid YAML.
+func Decode(data []byte) (*Spec, error) {
+	var s Spec
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Encode encodes a spec in the given format: FormatYAML or FormatJSON.
+func Encode(s *Spec, format string) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(s); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatJSON:
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+	return nil, fmt.Errorf("unknown spec format %q", format)
+}
+
+// Save writes the spec to path in the format given by its extension (see FileFormat).
+func Save(s *Spec, path string) error {
+	_, err := Write(s, path)
+	return err
+}
+
+// Write is Save, except that the file is left untouched, and false returned,
+// when its content is already up to date.
+func Write(s *Spec, path string) (bool, error) {
+	data, err := Encode(s, FileFormat(path))
+	if err != nil {
+		return false, err
+	}
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
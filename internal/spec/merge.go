@@ -0,0 +1,180 @@
+package spec
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Info policies of MergeOptions.
+const (
+	InfoFirst  = "first"  // the first spec's info, with empty fields filled from the following ones
+	InfoLast   = "last"   // the last spec's info, with empty fields filled from the preceding ones
+	InfoStrict = "strict" // like InfoFirst, but a field set to different values is a conflict unless MergeOptions.Info sets it
+)
+
+// MergeOptions configures MergeWith.
+type MergeOptions struct {
+	// Services names the service of each spec, in order. Empty names default
+	// to the spec's info title, or "spec N" without one.
+	Services []string
+	// Namespace prefixes socket names with their service, e.g. chat.Room, and
+	// renames a server that differs from one declared before in the same way.
+	Namespace bool
+	// InfoPolicy is InfoFirst (the default), InfoLast or InfoStrict.
+	InfoPolicy string
+	// Info overrides the merged info where its fields are set, e.g. with the title of a combined portal.
+	Info Info
+}
+
+// ConflictError lists the conflicts that stopped specs from being merged.
+type ConflictError struct {
+	Conflicts []string
+}
+
+func (e *ConflictError) Error() string {
+	if len(e.Conflicts) == 1 {
+		return e.Conflicts[0]
+	}
+	return fmt.Sprintf("%d conflicts: %s", len(e.Conflicts), strings.Join(e.Conflicts, "; "))
+}
+
+// Merge combines specs with the default MergeOptions.
+func Merge(specs ...*Spec) (*Spec, error) {
+	return MergeWith(MergeOptions{}, specs...)
+}
+
+// MergeWith combines specs, e.g. of several services, into one. Each socket
+// records its service. Servers with the same name must be identical and are
+// kept once. Sockets are appended in order and keep to the servers of their
+// own spec. Sockets with the same name, and sockets with the same URL on a
+// common server, are conflicts; all of them are returned in a *ConflictError.
+func MergeWith(opts MergeOptions, specs ...*Spec) (*Spec, error) {
+	switch opts.InfoPolicy {
+	case "", InfoFirst, InfoLast, InfoStrict:
+	default:
+		return nil, fmt.Errorf("unknown info policy %q, want first, last or strict", opts.InfoPolicy)
+	}
+	var conflicts []string
+	conflict := func(format string, args ...interface{}) {
+		conflicts = append(conflicts, fmt.Sprintf(format, args...))
+	}
+
+	services := make([]string, len(specs))
+	for i, s := range specs {
+		if i < len(opts.Services) {
+			services[i] = opts.Services[i]
+		}
+		services[i] = cmp.Or(services[i], s.Info.Title, fmt.Sprintf("spec %d", i+1))
+	}
+
+	merged := &Spec{}
+	for i := range specs {
+		if opts.InfoPolicy == InfoLast {
+			i = len(specs) - 1 - i
+		}
+		if opts.InfoPolicy == InfoStrict {
+			// Fields set in opts.Info are overridden, so they cannot conflict
+			for _, c := range infoConflicts(mergeInfo(opts.Info, merged.Info), mergeInfo(opts.Info, specs[i].Info)) {
+				conflict("%s of %s differs from the one before", c, services[i])
+			}
+		}
+		merged.Info = mergeInfo(merged.Info, specs[i].Info)
+	}
+	merged.Info = mergeInfo(opts.Info, merged.Info)
+
+	serverOwners := map[string]string{}
+	socketOwners := map[string]string{}
+	var placed []Socket // sockets in the merged spec, to find URL conflicts
+	for i, s := range specs {
+		service := services[i]
+		renamed := map[string]string{}
+		for _, srv := range s.Servers {
+			existing := merged.Server(srv.Name)
+			switch {
+			case existing == nil:
+			case reflect.DeepEqual(*existing, srv):
+				continue
+			case opts.Namespace:
+				renamed[srv.Name] = service + "." + srv.Name
+				srv.Name = renamed[srv.Name]
+			default:
+				conflict("server %q of %s differs from the one of %s", srv.Name, service, serverOwners[srv.Name])
+				continue
+			}
+			serverOwners[srv.Name] = service
+			merged.Servers = append(merged.Servers, srv)
+		}
+
+		for _, socket := range s.Sockets {
+			// A socket without servers is available on every server of its own spec only
+			var servers []string
+			for _, name := range socket.ServerNames(s) {
+				servers = append(servers, cmp.Or(renamed[name], name))
+			}
+			socket.Servers = servers
+			socket.Service = cmp.Or(socket.Service, service)
+			if opts.Namespace {
+				socket.Name = service + "." + socket.Name
+			}
+			if owner, ok := socketOwners[socket.Name]; ok {
+				conflict("socket %q of %s is also declared by %s", socket.Name, service, owner)
+			}
+			socketOwners[socket.Name] = service
+			for _, other := range placed {
+				if other.URL == socket.URL && (!socket.IsRelative() || shareServer(other.Servers, socket.Servers)) {
+					conflict("socket %q of %s has the same URL %q as %q of %s", socket.Name, service, socket.URL, other.Name, other.Service)
+				}
+			}
+			placed = append(placed, socket)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, &ConflictError{Conflicts: conflicts}
+	}
+	merged.Sockets = placed
+	return merged, nil
+}
+
+// shareServer reports whether two sockets are available on a common server.
+// A socket without servers is available on all of them.
+func shareServer(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(a, func(name string) bool { return slices.Contains(b, name) })
+}
+
+// mergeInfo fills the empty fields of info from other.
+func mergeInfo(info, other Info) Info {
+	info.Title = cmp.Or(info.Title, other.Title)
+	info.Version = cmp.Or(info.Version, other.Version)
+	info.Description = cmp.Or(info.Description, other.Description)
+	info.Contact.Name = cmp.Or(info.Contact.Name, other.Contact.Name)
+	info.Contact.Email = cmp.Or(info.Contact.Email, other.Contact.Email)
+	info.License.Name = cmp.Or(info.License.Name, other.License.Name)
+	info.License.URL = cmp.Or(info.License.URL, other.License.URL)
+	return info
+}
+
+// infoConflicts returns the fields set to different values in both infos.
+func infoConflicts(a, b Info) []string {
+	fields := []struct{ name, a, b string }{
+		{"info.title", a.Title, b.Title},
+		{"info.version", a.Version, b.Version},
+		{"info.description", a.Description, b.Description},
+		{"info.contact.name", a.Contact.Name, b.Contact.Name},
+		{"info.contact.email", a.Contact.Email, b.Contact.Email},
+		{"info.license.name", a.License.Name, b.License.Name},
+		{"info.license.url", a.License.URL, b.License.URL},
+	}
+	var conflicts []string
+	for _, f := range fields {
+		if f.a != "" && f.b != "" && f.a != f.b {
+			conflicts = append(conflicts, fmt.Sprintf("%s %q", f.name, f.b))
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,177 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// summary lists what a merged spec declares, one line per item.
+func summary(s *Spec) []string {
+	lines := []string{fmt.Sprintf("info %s %s %q", s.Info.Title, s.Info.Version, s.Info.Description)}
+	for _, srv := range s.Servers {
+		lines = append(lines, fmt.Sprintf("server %s %s", srv.Name, srv.URL))
+	}
+	for _, sock := range s.Sockets {
+		lines = append(lines, fmt.Sprintf("socket %s %s of %s on %s", sock.Name, sock.URL, sock.Service, strings.Join(sock.Servers, ",")))
+	}
+	return lines
+}
+
+func TestMergeWith(t *testing.T) {
+	prod := Server{Name: "production", URL: "wss://api.example.com"}
+	chat := func() *Spec {
+		return &Spec{
+			Info:    Info{Title: "Chat", Version: "1.0.0", Description: "Chat rooms"},
+			Servers: []Server{prod},
+			Sockets: []Socket{{Name: "Room", URL: "/ws/chat"}},
+		}
+	}
+	billing := func() *Spec {
+		return &Spec{
+			Info:    Info{Title: "Billing", Version: "2.0.0"},
+			Servers: []Server{prod},
+			Sockets: []Socket{{Name: "Invoices", URL: "/ws/invoices"}},
+		}
+	}
+	tests := []struct {
+		name    string
+		opts    MergeOptions
+		specs   []*Spec
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "identical servers are kept once",
+			specs: []*Spec{chat(), billing()},
+			want: []string{
+				`info Chat 1.0.0 "Chat rooms"`,
+				"server production wss://api.example.com",
+				"socket Room /ws/chat of Chat on production",
+				"socket Invoices /ws/invoices of Billing on production",
+			},
+		},
+		{
+			name: "duplicate socket name",
+			specs: []*Spec{chat(), func() *Spec {
+				s := billing()
+				s.Sockets[0].Name = "Room"
+				return s
+			}()},
+			wantErr: `socket "Room" of Billing is also declared by Chat`,
+		},
+		{
+			name: "duplicate URL on a common server",
+			specs: []*Spec{chat(), func() *Spec {
+				s := billing()
+				s.Sockets[0].URL = "/ws/chat"
+				return s
+			}()},
+			wantErr: `socket "Invoices" of Billing has the same URL "/ws/chat" as "Room" of Chat`,
+		},
+		{
+			name: "conflicting servers",
+			specs: []*Spec{chat(), func() *Spec {
+				s := billing()
+				s.Servers[0].URL = "wss://billing.example.com"
+				return s
+			}()},
+			wantErr: `server "production" of Billing differs from the one of Chat`,
+		},
+		{
+			name: "namespaced rename",
+			opts: MergeOptions{Namespace: true, Services: []string{"chat", "billing"}},
+			specs: []*Spec{chat(), func() *Spec {
+				s := billing()
+				s.Servers[0].URL = "wss://billing.example.com"
+				s.Sockets[0].Name = "Room"
+				return s
+			}()},
+			want: []string{
+				`info Chat 1.0.0 "Chat rooms"`,
+				"server production wss://api.example.com",
+				"server billing.production wss://billing.example.com",
+				"socket chat.Room /ws/chat of chat on production",
+				"socket billing.Room /ws/invoices of billing on billing.production",
+			},
+		},
+		{
+			name:  "info first fills empty fields from later specs",
+			opts:  MergeOptions{InfoPolicy: InfoFirst},
+			specs: []*Spec{billing(), chat()},
+			want: []string{
+				`info Billing 2.0.0 "Chat rooms"`,
+				"server production wss://api.example.com",
+				"socket Invoices /ws/invoices of Billing on production",
+				"socket Room /ws/chat of Chat on production",
+			},
+		},
+		{
+			name:  "info last",
+			opts:  MergeOptions{InfoPolicy: InfoLast},
+			specs: []*Spec{chat(), billing()},
+			want: []string{
+				`info Billing 2.0.0 "Chat rooms"`,
+				"server production wss://api.example.com",
+				"socket Room /ws/chat of Chat on production",
+				"socket Invoices /ws/invoices of Billing on production",
+			},
+		},
+		{
+			name:    "info strict",
+			opts:    MergeOptions{InfoPolicy: InfoStrict},
+			specs:   []*Spec{chat(), billing()},
+			wantErr: `2 conflicts: info.title "Billing" of Billing differs from the one before; info.version "2.0.0" of Billing differs from the one before`,
+		},
+		{
+			name:  "info strict with the conflicting fields overridden",
+			opts:  MergeOptions{InfoPolicy: InfoStrict, Info: Info{Title: "Portal", Version: "3.0.0"}},
+			specs: []*Spec{chat(), billing()},
+			want: []string{
+				`info Portal 3.0.0 "Chat rooms"`,
+				"server production wss://api.example.com",
+				"socket Room /ws/chat of Chat on production",
+				"socket Invoices /ws/invoices of Billing on production",
+			},
+		},
+		{
+			name:    "unknown info policy",
+			opts:    MergeOptions{InfoPolicy: "newest"},
+			specs:   []*Spec{chat()},
+			wantErr: `unknown info policy "newest", want first, last or strict`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeWith(tt.opts, tt.specs...)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("MergeWith error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lines := summary(got); !reflect.DeepEqual(lines, tt.want) {
+				t.Errorf("MergeWith =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestMergeConflictError(t *testing.T) {
+	a := &Spec{Info: Info{Title: "A"}, Sockets: []Socket{{Name: "Room", URL: "/a"}, {Name: "Lobby", URL: "/b"}}}
+	b := &Spec{Info: Info{Title: "B"}, Sockets: []Socket{{Name: "Room", URL: "/c"}, {Name: "Lobby", URL: "/d"}}}
+	_, err := Merge(a, b)
+	var conflicts *ConflictError
+	if !errors.As(err, &conflicts) {
+		t.Fatalf("Merge error = %v, want a *ConflictError", err)
+	}
+	want := []string{`socket "Room" of B is also declared by A`, `socket "Lobby" of B is also declared by A`}
+	if !reflect.DeepEqual(conflicts.Conflicts, want) {
+		t.Errorf("conflicts = %q, want %q", conflicts.Conflicts, want)
+	}
+}
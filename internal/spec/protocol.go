@@ -0,0 +1,53 @@
+package spec
+
+// HasState reports whether the protocol declares a state with the given name.
+func (p *Protocol) HasState(name string) bool {
+	for _, st := range p.States {
+		if st.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Constrains reports whether the message type appears in any transition.
+// Messages that are not part of the state machine are allowed in every state.
+func (p *Protocol) Constrains(message string) bool {
+	for _, t := range p.Transitions {
+		if t.Message == message {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the state reached by exchanging message in state from.
+func (p *Protocol) Next(from, message string) (string, bool) {
+	for _, t := range p.Transitions {
+		if t.From == from && t.Message == message {
+			return t.To, true
+		}
+	}
+	return "", false
+}
+
+// Reachable returns the set of states reachable from the initial state.
+func (p *Protocol) Reachable() map[string]bool {
+	seen := map[string]bool{}
+	if p.Initial == "" {
+		return seen
+	}
+	queue := []string{p.Initial}
+	seen[p.Initial] = true
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, t := range p.Transitions {
+			if t.From == cur && !seen[t.To] {
+				seen[t.To] = true
+				queue = append(queue, t.To)
+			}
+		}
+	}
+	return seen
+}
@@ -0,0 +1,310 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema describes the shape of a payload using a subset of JSON Schema.
+type Schema struct {
+	Type        string        `yaml:"type,omitempty" json:"type,omitempty"` // string, integer, number, boolean, array, object; empty for any value
+	Format      string        `yaml:"format,omitempty" json:"format,omitempty"`
+	Description string        `yaml:"description,omitempty" json:"description,omitempty"`
+	Enum        []interface{} `yaml:"enum,omitempty" json:"enum,omitempty"`
+	EnumValues  []EnumValue   `yaml:"enumValues,omitempty" json:"enumValues,omitempty"` // names of Enum from typed constants
+	Default     interface{}   `yaml:"default,omitempty" json:"default,omitempty"`
+	Minimum     *float64      `yaml:"minimum,omitempty" json:"minimum,omitempty"`
+	Maximum     *float64      `yaml:"maximum,omitempty" json:"maximum,omitempty"`
+	MinLength   *int          `yaml:"minLength,omitempty" json:"minLength,omitempty"`
+	MaxLength   *int          `yaml:"maxLength,omitempty" json:"maxLength,omitempty"`
+	MinItems    *int          `yaml:"minItems,omitempty" json:"minItems,omitempty"`
+	MaxItems    *int          `yaml:"maxItems,omitempty" json:"maxItems,omitempty"`
+	Deprecated  bool          `yaml:"deprecated,omitempty" json:"deprecated,omitempty"`
+	Properties  Properties    `yaml:"properties,omitempty" json:"properties,omitempty"`
+	Required    []string      `yaml:"required,omitempty" json:"required,omitempty"`
+	Items       *Schema       `yaml:"items,omitempty" json:"items,omitempty"`
+}
+
+// EnumValue is an allowed value declared as a typed constant.
+type EnumValue struct {
+	Value       interface{} `yaml:"value" json:"value"`
+	Name        string      `yaml:"name" json:"name"`
+	Description string      `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// Properties are the fields of an object schema. They keep their declaration
+// order and are written as a YAML or JSON mapping.
+type Properties []Property
+
+// Property is a named field of an object schema.
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Get returns the schema of the named property, or nil.
+func (p Properties) Get(name string) *Schema {
+	for _, prop := range p {
+		if prop.Name == name {
+			return prop.Schema
+		}
+	}
+	return nil
+}
+
+func (p Properties) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, prop := range p {
+		var value yaml.Node
+		if err := value.Encode(prop.Schema); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: prop.Name}, &value)
+	}
+	return node, nil
+}
+
+func (p *Properties) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: properties must be a mapping", node.Line)
+	}
+	*p = nil
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var s Schema
+		if err := node.Content[i+1].Decode(&s); err != nil {
+			return err
+		}
+		*p = append(*p, Property{Name: node.Content[i].Value, Schema: &s})
+	}
+	return nil
+}
+
+func (p Properties) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	for i, prop := range p {
+		k, err := json.Marshal(prop.Name)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(prop.Schema)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(append(append(buf, k...), ':'), v...)
+	}
+	return append(buf, '}'), nil
+}
+
+// UnmarshalJSON reads properties in order; JSON is valid YAML.
+func (p *Properties) UnmarshalJSON(data []byte) error {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	if len(node.Content) == 0 {
+		return nil
+	}
+	return p.UnmarshalYAML(node.Content[0])
+}
+
+// Field is a property of an object schema, found by Fields.
+type Field struct {
+	Path     string // dotted path, with [] for array items: author.name, tags[].id
+	Schema   *Schema
+	Required bool
+}
+
+// Fields lists the properties of an object schema in order, followed
+// directly by the properties of nested objects and array items.
+func (s *Schema) Fields() []Field {
+	var fields []Field
+	var walk func(s *Schema, prefix string)
+	walk = func(s *Schema, prefix string) {
+		for _, prop := range s.Properties {
+			path := prefix + prop.Name
+			fields = append(fields, Field{Path: path, Schema: prop.Schema, Required: slices.Contains(s.Required, prop.Name)})
+			if prop.Schema == nil {
+				continue
+			}
+			walk(prop.Schema, path+".")
+			if prop.Schema.Items != nil {
+				walk(prop.Schema.Items, path+"[].")
+			}
+		}
+	}
+	if s != nil {
+		walk(s, "")
+	}
+	return fields
+}
+
+// Example is a named sample value of a message or error.
+type Example struct {
+	Name        string      `yaml:"name" json:"name"`
+	Description string      `yaml:"description,omitempty" json:"description,omitempty"`
+	Value       interface{} `yaml:"value" json:"value"`
+}
+
+// Validate checks a decoded JSON or YAML value against the schema and returns
+// one message per problem, prefixed with the path of the offending field.
+// Objects with declared properties may not contain other fields.
+func (s *Schema) Validate(v interface{}) []string {
+	return s.validate("", v)
+}
+
+func (s *Schema) validate(path string, v interface{}) []string {
+	if s == nil || v == nil {
+		return nil
+	}
+	at := func(format string, args ...interface{}) []string {
+		msg := fmt.Sprintf(format, args...)
+		if path != "" {
+			msg = path + ": " + msg
+		}
+		return []string{msg}
+	}
+	got := valueType(v)
+	switch {
+	case s.Type == "":
+	case s.Type == "number" && got == "integer":
+	case s.Type != got:
+		return at("expected %s, got %s", s.Type, got)
+	}
+	var errs []string
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e interface{}) bool { return sameValue(e, v) }) {
+		errs = append(errs, at("%v is not one of %v", v, s.Enum)...)
+	}
+	switch val := v.(type) {
+	case string:
+		n := len([]rune(val))
+		if s.MinLength != nil && n < *s.MinLength {
+			errs = append(errs, at("shorter than %d characters", *s.MinLength)...)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			errs = append(errs, at("longer than %d characters", *s.MaxLength)...)
+		}
+		if check, ok := formats[s.Format]; ok && !check(val) {
+			errs = append(errs, at("%q is not a valid %s", val, s.Format)...)
+		}
+	case int, int64, uint64, float64:
+		n, _ := number(val)
+		if s.Minimum != nil && n < *s.Minimum {
+			errs = append(errs, at("less than %v", *s.Minimum)...)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			errs = append(errs, at("greater than %v", *s.Maximum)...)
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				errs = append(errs, at("missing required field %q", name)...)
+			}
+		}
+		if len(s.Properties) == 0 {
+			break
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			prop := s.Properties.Get(k)
+			if prop == nil {
+				errs = append(errs, at("unknown field %q", k)...)
+				continue
+			}
+			errs = append(errs, prop.validate(join(path, k), val[k])...)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			errs = append(errs, at("fewer than %d items", *s.MinItems)...)
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			errs = append(errs, at("more than %d items", *s.MaxItems)...)
+		}
+		for i, item := range val {
+			errs = append(errs, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+		}
+	}
+	return errs
+}
+
+func join(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// valueType returns the JSON Schema type of a value decoded from JSON or YAML.
+func valueType(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		if val == math.Trunc(val) && !math.IsInf(val, 0) {
+			return "integer"
+		}
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return "unknown"
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// formats are the string formats Validate checks; others are documentation only.
+var formats = map[string]func(string) bool{
+	"email": func(s string) bool {
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	},
+	"uuid": uuidPattern.MatchString,
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+}
+
+// number returns a decoded numeric value as float64.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// sameValue compares decoded values, treating numbers of different Go types as equal.
+func sameValue(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,116 @@
+package spec
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var placeholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// Placeholders returns the names of the {variable} placeholders in a URL template, in order.
+func Placeholders(template string) []string {
+	var names []string
+	for _, m := range placeholder.FindAllStringSubmatch(template, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// Server returns the server with the given name, or nil.
+func (s *Spec) Server(name string) *Server {
+	for i := range s.Servers {
+		if s.Servers[i].Name == name {
+			return &s.Servers[i]
+		}
+	}
+	return nil
+}
+
+// Variable returns the variable with the given name, or nil.
+func (s *Server) Variable(name string) *ServerVariable {
+	for i := range s.Variables {
+		if s.Variables[i].Name == name {
+			return &s.Variables[i]
+		}
+	}
+	return nil
+}
+
+// Allows reports whether value may be used for the variable: any value
+// unless the variable declares an enum.
+func (v *ServerVariable) Allows(value string) bool {
+	return len(v.Enum) == 0 || slices.Contains(v.Enum, value)
+}
+
+// Resolve expands the server URL, taking variables from vars and falling back to their defaults.
+// A value outside a variable's enum is an error.
+func (s *Server) Resolve(vars map[string]string) (string, error) {
+	var err error
+	resolved := placeholder.ReplaceAllStringFunc(s.URL, func(m string) string {
+		name := m[1 : len(m)-1]
+		variable := s.Variable(name)
+		if v, ok := vars[name]; ok && v != "" {
+			if variable != nil && !variable.Allows(v) && err == nil {
+				err = fmt.Errorf("server %q: variable %q must be one of %s, got %q", s.Name, name, strings.Join(variable.Enum, ", "), v)
+			}
+			return v
+		}
+		if variable != nil {
+			return variable.Default
+		}
+		return m
+	})
+	if err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// IsRelative reports whether a socket URL is a path to be resolved against a server.
+func (s *Socket) IsRelative() bool {
+	return !strings.Contains(s.URL, "://")
+}
+
+// ServerNames returns the servers the socket is available on: its own list, or every server in the spec.
+func (s *Socket) ServerNames(sp *Spec) []string {
+	if len(s.Servers) > 0 {
+		return s.Servers
+	}
+	names := make([]string, 0, len(sp.Servers))
+	for _, srv := range sp.Servers {
+		names = append(names, srv.Name)
+	}
+	return names
+}
+
+// Param returns the connection parameter with the given name, or nil.
+func (s *Socket) Param(name string) *ConnectionParam {
+	for i := range s.ConnectionParams {
+		if s.ConnectionParams[i].Name == name {
+			return &s.ConnectionParams[i]
+		}
+	}
+	return nil
+}
+
+// ExpandPath fills the {param} placeholders of the socket URL with escaped values.
+// Placeholders without a value are left in place.
+func (s *Socket) ExpandPath(values map[string]string) string {
+	return placeholder.ReplaceAllStringFunc(s.URL, func(m string) string {
+		if v, ok := values[m[1:len(m)-1]]; ok && v != "" {
+			return url.PathEscape(v)
+		}
+		return m
+	})
+}
+
+// JoinURL appends a relative socket path to a resolved server URL.
+func JoinURL(base, path string) string {
+	if path == "" {
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
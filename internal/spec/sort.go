@@ -0,0 +1,58 @@
+package spec
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Sort orders sockets by name and their messages by type, and sorts the keys
+// of JSON payloads. Servers, parameters and protocol states keep their
+// declared order, which carries meaning (the first server is the default).
+func (s *Spec) Sort() {
+	sort.SliceStable(s.Sockets, func(i, j int) bool { return s.Sockets[i].Name < s.Sockets[j].Name })
+	for i := range s.Sockets {
+		s.Sockets[i].sortMessages()
+	}
+}
+
+func (s *Socket) sortMessages() {
+	sort.SliceStable(s.GroupedMessages, func(i, j int) bool {
+		return s.GroupedMessages[i].Type < s.GroupedMessages[j].Type
+	})
+	sort.SliceStable(s.Messages, func(i, j int) bool {
+		if s.Messages[i].Type != s.Messages[j].Type {
+			return s.Messages[i].Type < s.Messages[j].Type
+		}
+		return s.Messages[i].Direction < s.Messages[j].Direction
+	})
+	for i := range s.GroupedMessages {
+		g := &s.GroupedMessages[i]
+		if g.Send != nil {
+			g.Send.Payload = sortedPayload(g.Send.Payload)
+		}
+		if g.Receive != nil {
+			g.Receive.Payload = sortedPayload(g.Receive.Payload)
+		}
+	}
+	for i := range s.Messages {
+		s.Messages[i].Payload = sortedPayload(s.Messages[i].Payload)
+	}
+}
+
+// sortedPayload re-encodes a JSON payload with its object keys sorted.
+// Anything that is not a JSON string is returned unchanged.
+func sortedPayload(payload interface{}) interface{} {
+	str, ok := payload.(string)
+	if !ok {
+		return payload
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(str), &v); err != nil {
+		return payload
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return payload
+	}
+	return string(b)
+}
@@ -21,9 +21,27 @@ type Info struct {
 	License     License `yaml:"license,omitempty" json:"license,omitempty"`
 }
 
+// Server is an environment the API is deployed to, e.g. local, staging or production.
+// URL may contain {variable} placeholders declared in Variables.
+type Server struct {
+	Name        string           `yaml:"name" json:"name"`
+	URL         string           `yaml:"url" json:"url"`
+	Description string           `yaml:"description,omitempty" json:"description,omitempty"`
+	Variables   []ServerVariable `yaml:"variables,omitempty" json:"variables,omitempty"`
+}
+
+// ServerVariable is a placeholder in a server URL.
+type ServerVariable struct {
+	Name        string   `yaml:"name" json:"name"`
+	Default     string   `yaml:"default" json:"default"`
+	Enum        []string `yaml:"enum,omitempty" json:"enum,omitempty"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
 // Spec is the root of the WebSocket API documentation.
 type Spec struct {
 	Info    Info     `yaml:"info" json:"info"`
+	Servers []Server `yaml:"servers,omitempty" json:"servers,omitempty"`
 	Sockets []Socket `yaml:"sockets" json:"sockets"`
 }
 
@@ -31,18 +49,81 @@ type Spec struct {
 type Socket struct {
 	Name             string            `yaml:"name" json:"name"`
 	URL              string            `yaml:"url" json:"url"`
+	Servers          []string          `yaml:"servers,omitempty" json:"servers,omitempty"`
 	Description      string            `yaml:"description" json:"description"`
 	Group            string            `yaml:"group,omitempty" json:"group,omitempty"`
+	Service          string            `yaml:"service,omitempty" json:"service,omitempty"` // owning service in a merged spec
 	Tags             []string          `yaml:"tags,omitempty" json:"tags,omitempty"`
 	ConnectionParams []ConnectionParam `yaml:"connectionParams,omitempty" json:"connectionParams,omitempty"`
 	Messages         []Message         `yaml:"messages" json:"messages"`
 	GroupedMessages  []GroupedMessage  `yaml:"groupedMessages,omitempty" json:"groupedMessages,omitempty"`
+
+	// Connection lifecycle
+	Handshake      []HandshakeResponse `yaml:"handshake,omitempty" json:"handshake,omitempty"`
+	Heartbeat      *Heartbeat          `yaml:"heartbeat,omitempty" json:"heartbeat,omitempty"`
+	IdleTimeout    string              `yaml:"idleTimeout,omitempty" json:"idleTimeout,omitempty"`       // Go duration, e.g. 5m
+	MaxMessageSize int64               `yaml:"maxMessageSize,omitempty" json:"maxMessageSize,omitempty"` // bytes
+	RateLimit      *RateLimit          `yaml:"rateLimit,omitempty" json:"rateLimit,omitempty"`
+	CloseCodes     []CloseCode         `yaml:"closeCodes,omitempty" json:"closeCodes,omitempty"`
+
+	// Message ordering rules
+	Protocol *Protocol `yaml:"protocol,omitempty" json:"protocol,omitempty"`
+}
+
+// HandshakeResponse documents an HTTP response returned instead of upgrading the connection.
+type HandshakeResponse struct {
+	Status      int         `yaml:"status" json:"status"`
+	Description string      `yaml:"description,omitempty" json:"description,omitempty"`
+	Body        interface{} `yaml:"body,omitempty" json:"body,omitempty"`
+}
+
+// Heartbeat describes how liveness of a connection is checked.
+type Heartbeat struct {
+	Mode     string `yaml:"mode" json:"mode"`                           // ping | application
+	Interval string `yaml:"interval" json:"interval"`                   // Go duration, e.g. 30s
+	Timeout  string `yaml:"timeout,omitempty" json:"timeout,omitempty"` // Go duration, e.g. 10s
+	Message  string `yaml:"message,omitempty" json:"message,omitempty"` // message type for application heartbeats
+}
+
+// RateLimit describes how many messages a client may send in a time window.
+type RateLimit struct {
+	Messages    int    `yaml:"messages" json:"messages"`
+	Window      string `yaml:"window" json:"window"` // Go duration, e.g. 1s
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// Protocol describes the states a connection goes through and which messages move it between them.
+type Protocol struct {
+	Initial     string       `yaml:"initial" json:"initial"`
+	States      []State      `yaml:"states" json:"states"`
+	Transitions []Transition `yaml:"transitions" json:"transitions"`
+}
+
+// State is a named protocol state.
+type State struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Final       bool   `yaml:"final,omitempty" json:"final,omitempty"`
+}
+
+// Transition moves a connection from one state to another when Message is exchanged.
+type Transition struct {
+	From        string `yaml:"from" json:"from"`
+	To          string `yaml:"to" json:"to"`
+	Message     string `yaml:"message" json:"message"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// CloseCode documents a WebSocket close code used by the endpoint.
+type CloseCode struct {
+	Code        int    `yaml:"code" json:"code"`
+	Description string `yaml:"description" json:"description"`
 }
 
 // ConnectionParam represents a connection parameter for a WebSocket endpoint.
 type ConnectionParam struct {
 	Name        string `yaml:"name" json:"name"`
-	In          string `yaml:"in" json:"in"` // query, header
+	In          string `yaml:"in" json:"in"` // query, header, path
 	Type        string `yaml:"type" json:"type"`
 	Required    bool   `yaml:"required" json:"required"`
 	Description string `yaml:"description,omitempty" json:"description,omitempty"`
@@ -54,20 +135,24 @@ type Message struct {
 	Direction   string      `yaml:"direction" json:"direction"` // send | receive
 	Description string      `yaml:"description,omitempty" json:"description,omitempty"`
 	Payload     interface{} `yaml:"payload" json:"payload"`
+	Schema      *Schema     `yaml:"schema,omitempty" json:"schema,omitempty"` // shape of a struct payload
 	Example     interface{} `yaml:"example,omitempty" json:"example,omitempty"`
+	Examples    []Example   `yaml:"examples,omitempty" json:"examples,omitempty"`
 	Errors      []Error     `yaml:"errors,omitempty" json:"errors,omitempty"`
 	Deprecated  bool        `yaml:"deprecated,omitempty" json:"deprecated,omitempty"`
 	Tags        []string    `yaml:"tags,omitempty" json:"tags,omitempty"`
+	// Inferred marks a draft found in handler code rather than documented by annotations.
+	Inferred bool `yaml:"inferred,omitempty" json:"inferred,omitempty"`
 }
 
 // GroupedMessage represents a message type that can have both send and receive directions
 type GroupedMessage struct {
-	Type        string      `yaml:"type" json:"type"`
-	Description string      `yaml:"description,omitempty" json:"description,omitempty"`
-	Send        *Message    `yaml:"send,omitempty" json:"send,omitempty"`
-	Receive     *Message    `yaml:"receive,omitempty" json:"receive,omitempty"`
-	Deprecated  bool        `yaml:"deprecated,omitempty" json:"deprecated,omitempty"`
-	Tags        []string    `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Type        string   `yaml:"type" json:"type"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Send        *Message `yaml:"send,omitempty" json:"send,omitempty"`
+	Receive     *Message `yaml:"receive,omitempty" json:"receive,omitempty"`
+	Deprecated  bool     `yaml:"deprecated,omitempty" json:"deprecated,omitempty"`
+	Tags        []string `yaml:"tags,omitempty" json:"tags,omitempty"`
 }
 
 // Error represents an error type for a WebSocket endpoint.
@@ -75,4 +160,5 @@ type Error struct {
 	Code        string      `yaml:"code" json:"code"`
 	Description string      `yaml:"description" json:"description"`
 	Example     interface{} `yaml:"example,omitempty" json:"example,omitempty"`
+	Examples    []Example   `yaml:"examples,omitempty" json:"examples,omitempty"`
 }
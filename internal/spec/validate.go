@@ -0,0 +1,297 @@
+package spec
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Validate checks that the spec is complete and consistent: required
+// fields, server and path parameter references, lifecycle settings, the
+// protocol state machine and examples against their schemas. It returns one
+// message per problem.
+func (s *Spec) Validate() []string {
+	var errors []string
+
+	// Validate info
+	if s.Info.Title == "" {
+		errors = append(errors, "Info.Title is required")
+	}
+	if s.Info.Version == "" {
+		errors = append(errors, "Info.Version is required")
+	}
+
+	errors = append(errors, validateServers(s)...)
+
+	// Validate sockets
+	if len(s.Sockets) == 0 {
+		errors = append(errors, "At least one WebSocket endpoint is required")
+	}
+
+	for i, socket := range s.Sockets {
+		if socket.Name == "" {
+			errors = append(errors, fmt.Sprintf("Socket[%d].Name is required", i))
+		}
+		if socket.URL == "" {
+			errors = append(errors, fmt.Sprintf("Socket[%d].URL is required", i))
+		}
+
+		// Validate grouped messages
+		for j, groupedMsg := range socket.GroupedMessages {
+			if groupedMsg.Type == "" {
+				errors = append(errors, fmt.Sprintf("Socket[%d].GroupedMessages[%d].Type is required", i, j))
+			}
+			if groupedMsg.Send == nil && groupedMsg.Receive == nil {
+				errors = append(errors, fmt.Sprintf("Socket[%d].GroupedMessages[%d] must have at least one Send or Receive message", i, j))
+			}
+		}
+
+		// Validate legacy messages (for backward compatibility)
+		for j, msg := range socket.Messages {
+			if msg.Type == "" {
+				errors = append(errors, fmt.Sprintf("Socket[%d].Messages[%d].Type is required", i, j))
+			}
+			if msg.Direction == "" {
+				errors = append(errors, fmt.Sprintf("Socket[%d].Messages[%d].Direction is required", i, j))
+			}
+		}
+
+		for _, name := range socket.Servers {
+			if s.Server(name) == nil {
+				errors = append(errors, fmt.Sprintf("Socket[%d].Servers references undeclared server %q", i, name))
+			}
+		}
+		errors = append(errors, validatePathParams(i, &socket)...)
+		errors = append(errors, validateExamples(i, &socket)...)
+
+		errors = append(errors, validateLifecycle(i, &socket)...)
+		errors = append(errors, validateProtocol(i, &socket)...)
+	}
+
+	return errors
+}
+
+// validateServers checks server names, URLs and that every URL placeholder is a declared variable.
+func validateServers(s *Spec) []string {
+	var errors []string
+	seen := map[string]bool{}
+	for i, srv := range s.Servers {
+		if srv.Name == "" {
+			errors = append(errors, fmt.Sprintf("Servers[%d].Name is required", i))
+		} else if seen[srv.Name] {
+			errors = append(errors, fmt.Sprintf("Servers[%d].Name %q is declared more than once", i, srv.Name))
+		}
+		seen[srv.Name] = true
+		if srv.URL == "" {
+			errors = append(errors, fmt.Sprintf("Servers[%d].URL is required", i))
+		}
+		for _, name := range Placeholders(srv.URL) {
+			if srv.Variable(name) == nil {
+				errors = append(errors, fmt.Sprintf("Servers[%d].URL uses undeclared variable %q", i, name))
+			}
+		}
+		for j, v := range srv.Variables {
+			if v.Name == "" {
+				errors = append(errors, fmt.Sprintf("Servers[%d].Variables[%d].Name is required", i, j))
+			}
+			if v.Default == "" {
+				errors = append(errors, fmt.Sprintf("Servers[%d].Variables[%d].Default is required", i, j))
+			}
+			if len(v.Enum) > 0 && !slices.Contains(v.Enum, v.Default) {
+				errors = append(errors, fmt.Sprintf("Servers[%d].Variables[%d].Default %q is not one of %v", i, j, v.Default, v.Enum))
+			}
+		}
+	}
+	return errors
+}
+
+// validatePathParams checks that URL placeholders and `in: path` parameters match up.
+func validatePathParams(i int, socket *Socket) []string {
+	var errors []string
+	placeholders := Placeholders(socket.URL)
+	for _, name := range placeholders {
+		param := socket.Param(name)
+		if param == nil {
+			errors = append(errors, fmt.Sprintf("Socket[%d].URL placeholder {%s} has no path parameter", i, name))
+		} else if param.In != "path" {
+			errors = append(errors, fmt.Sprintf("Socket[%d].ConnectionParams %q is used in the URL but declared in %s", i, name, param.In))
+		}
+	}
+	for j, param := range socket.ConnectionParams {
+		switch param.In {
+		case "query", "header":
+		case "path":
+			if !slices.Contains(placeholders, param.Name) {
+				errors = append(errors, fmt.Sprintf("Socket[%d].ConnectionParams[%d] %q is a path parameter but the URL has no {%s}", i, j, param.Name, param.Name))
+			}
+			if !param.Required {
+				errors = append(errors, fmt.Sprintf("Socket[%d].ConnectionParams[%d] %q is a path parameter and must be required", i, j, param.Name))
+			}
+		default:
+			errors = append(errors, fmt.Sprintf("Socket[%d].ConnectionParams[%d].In must be query, header or path", i, j))
+		}
+	}
+	return errors
+}
+
+// validateLifecycle checks handshake responses, heartbeat, limits and close codes of a socket.
+func validateLifecycle(i int, socket *Socket) []string {
+	var errors []string
+
+	for j, resp := range socket.Handshake {
+		if resp.Status < 400 || resp.Status > 599 {
+			errors = append(errors, fmt.Sprintf("Socket[%d].Handshake[%d].Status %d must be a 4xx or 5xx HTTP status", i, j, resp.Status))
+		}
+	}
+
+	if hb := socket.Heartbeat; hb != nil {
+		if hb.Mode != "ping" && hb.Mode != "application" {
+			errors = append(errors, fmt.Sprintf("Socket[%d].Heartbeat.Mode %q must be ping or application", i, hb.Mode))
+		}
+		interval, err := time.ParseDuration(hb.Interval)
+		if err != nil || interval <= 0 {
+			errors = append(errors, fmt.Sprintf("Socket[%d].Heartbeat.Interval %q is not a valid duration", i, hb.Interval))
+		}
+		if hb.Timeout != "" {
+			if timeout, err := time.ParseDuration(hb.Timeout); err != nil || timeout <= 0 {
+				errors = append(errors, fmt.Sprintf("Socket[%d].Heartbeat.Timeout %q is not a valid duration", i, hb.Timeout))
+			}
+		}
+		if hb.Mode == "application" && hb.Message == "" {
+			errors = append(errors, fmt.Sprintf("Socket[%d].Heartbeat.Message is required for application heartbeats", i))
+		}
+	}
+
+	if socket.IdleTimeout != "" {
+		if d, err := time.ParseDuration(socket.IdleTimeout); err != nil || d <= 0 {
+			errors = append(errors, fmt.Sprintf("Socket[%d].IdleTimeout %q is not a valid duration", i, socket.IdleTimeout))
+		}
+	}
+	if socket.MaxMessageSize < 0 {
+		errors = append(errors, fmt.Sprintf("Socket[%d].MaxMessageSize must be positive", i))
+	}
+
+	if rl := socket.RateLimit; rl != nil {
+		if rl.Messages <= 0 {
+			errors = append(errors, fmt.Sprintf("Socket[%d].RateLimit.Messages must be positive", i))
+		}
+		if d, err := time.ParseDuration(rl.Window); err != nil || d <= 0 {
+			errors = append(errors, fmt.Sprintf("Socket[%d].RateLimit.Window %q is not a valid duration", i, rl.Window))
+		}
+	}
+
+	seen := map[int]bool{}
+	for j, cc := range socket.CloseCodes {
+		switch {
+		case cc.Code == 1004 || cc.Code == 1005 || cc.Code == 1006 || cc.Code == 1015:
+			errors = append(errors, fmt.Sprintf("Socket[%d].CloseCodes[%d] %d is reserved and cannot be sent in a close frame", i, j, cc.Code))
+		case cc.Code < 1000 || (cc.Code > 1015 && cc.Code < 3000) || cc.Code > 4999:
+			errors = append(errors, fmt.Sprintf("Socket[%d].CloseCodes[%d] %d is outside the valid close code ranges (1000-1015, 3000-4999)", i, j, cc.Code))
+		}
+		if cc.Description == "" {
+			errors = append(errors, fmt.Sprintf("Socket[%d].CloseCodes[%d].Description is required", i, j))
+		}
+		if seen[cc.Code] {
+			errors = append(errors, fmt.Sprintf("Socket[%d].CloseCodes[%d] %d is declared more than once", i, j, cc.Code))
+		}
+		seen[cc.Code] = true
+	}
+
+	return errors
+}
+
+// validateProtocol checks that the protocol state machine is well formed and only references known messages.
+func validateProtocol(i int, socket *Socket) []string {
+	p := socket.Protocol
+	if p == nil {
+		return nil
+	}
+	var errors []string
+
+	states := map[string]bool{}
+	for j, st := range p.States {
+		if st.Name == "" {
+			errors = append(errors, fmt.Sprintf("Socket[%d].Protocol.States[%d].Name is required", i, j))
+			continue
+		}
+		if states[st.Name] {
+			errors = append(errors, fmt.Sprintf("Socket[%d].Protocol.States[%d] %q is declared more than once", i, j, st.Name))
+		}
+		states[st.Name] = true
+	}
+	if p.Initial == "" {
+		errors = append(errors, fmt.Sprintf("Socket[%d].Protocol.Initial is required", i))
+	} else if !states[p.Initial] {
+		errors = append(errors, fmt.Sprintf("Socket[%d].Protocol.Initial %q is not a declared state", i, p.Initial))
+	}
+
+	messages := map[string]bool{}
+	for _, gm := range socket.GroupedMessages {
+		messages[gm.Type] = true
+	}
+	for _, msg := range socket.Messages {
+		messages[msg.Type] = true
+	}
+
+	targets := map[string]string{}
+	for j, t := range p.Transitions {
+		if !states[t.From] {
+			errors = append(errors, fmt.Sprintf("Socket[%d].Protocol.Transitions[%d].From %q is not a declared state", i, j, t.From))
+		}
+		if !states[t.To] {
+			errors = append(errors, fmt.Sprintf("Socket[%d].Protocol.Transitions[%d].To %q is not a declared state", i, j, t.To))
+		}
+		if !messages[t.Message] {
+			errors = append(errors, fmt.Sprintf("Socket[%d].Protocol.Transitions[%d].Message %q is not a documented message", i, j, t.Message))
+		}
+		key := t.From + "\x00" + t.Message
+		if to, ok := targets[key]; ok && to != t.To {
+			errors = append(errors, fmt.Sprintf("Socket[%d].Protocol.Transitions[%d] %q in state %q leads to both %q and %q", i, j, t.Message, t.From, to, t.To))
+		}
+		targets[key] = t.To
+	}
+
+	if states[p.Initial] {
+		reachable := p.Reachable()
+		for _, st := range p.States {
+			if st.Name != "" && !reachable[st.Name] {
+				errors = append(errors, fmt.Sprintf("Socket[%d].Protocol state %q is unreachable from %q", i, st.Name, p.Initial))
+			}
+		}
+	}
+
+	return errors
+}
+
+// validateExamples checks that examples are named uniquely and match the payload schema.
+func validateExamples(i int, socket *Socket) []string {
+	var errors []string
+	check := func(path string, schema *Schema, examples []Example) {
+		seen := map[string]bool{}
+		for _, ex := range examples {
+			switch {
+			case ex.Name == "":
+				errors = append(errors, fmt.Sprintf("%s.Examples: example without a name", path))
+			case seen[ex.Name]:
+				errors = append(errors, fmt.Sprintf("%s.Examples: duplicate example %q", path, ex.Name))
+			}
+			seen[ex.Name] = true
+			for _, problem := range schema.Validate(ex.Value) {
+				errors = append(errors, fmt.Sprintf("%s.Examples[%q]: %s", path, ex.Name, problem))
+			}
+		}
+	}
+	for j, gm := range socket.GroupedMessages {
+		for k, msg := range []*Message{gm.Send, gm.Receive} {
+			if msg == nil {
+				continue
+			}
+			path := fmt.Sprintf("Socket[%d].GroupedMessages[%d].%s", i, j, []string{"Send", "Receive"}[k])
+			check(path, msg.Schema, msg.Examples)
+			for n, e := range msg.Errors {
+				check(fmt.Sprintf("%s.Errors[%d]", path, n), nil, e.Examples)
+			}
+		}
+	}
+	return errors
+}
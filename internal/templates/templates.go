@@ -0,0 +1,9 @@
+package templates
+
+import "embed"
+
+// FS holds the documentation UI, the starter spec, the Markdown export
+// templates and the scripts inlined into static builds of the UI.
+//
+//go:embed index.html wsapi.yaml logo.png markdown/*.tmpl vendor
+var FS embed.FS
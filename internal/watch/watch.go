@@ -0,0 +1,168 @@
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/muratmirgun/socketeer/internal/parser"
+)
+
+// Watcher keeps a parser.Index up to date with changes to the Go files below its directories.
+type Watcher struct {
+	Index *parser.Index
+	// OnChange is called after a batch of changes has been applied to the index.
+	OnChange func(changed []string)
+	// OnError is called for files that fail to parse; the index keeps their previous result.
+	OnError func(err error)
+	// Debounce groups events arriving within this window into one batch (default 200ms).
+	Debounce time.Duration
+}
+
+// Run watches until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+
+	for _, dir := range w.Index.Dirs() {
+		if err := addRecursive(fw, dir); err != nil {
+			return err
+		}
+	}
+
+	debounce := w.Debounce
+	if debounce == 0 {
+		debounce = 200 * time.Millisecond
+	}
+	pending := map[string]fsnotify.Op{}
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return nil
+			}
+			w.report(err)
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Create) {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					if err := addRecursive(fw, ev.Name); err != nil {
+						w.report(err)
+					}
+					w.indexDir(ev.Name, pending)
+					timer.Reset(debounce)
+				}
+			}
+			if strings.HasSuffix(ev.Name, ".go") || ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+				pending[ev.Name] |= ev.Op
+				timer.Reset(debounce)
+			}
+		case <-timer.C:
+			w.apply(pending)
+			pending = map[string]fsnotify.Op{}
+		}
+	}
+}
+
+// apply updates the index for a batch of events and reports the changed paths.
+func (w *Watcher) apply(pending map[string]fsnotify.Op) {
+	var changed []string
+	for path := range pending {
+		if _, err := os.Stat(path); err != nil {
+			if !w.Index.Remove(path) {
+				continue
+			}
+		} else if !strings.HasSuffix(path, ".go") {
+			continue
+		} else if err := w.Index.Update(path); err != nil {
+			w.report(err)
+			continue
+		}
+		changed = append(changed, path)
+	}
+	if len(changed) == 0 || w.OnChange == nil {
+		return
+	}
+	sort.Strings(changed)
+	w.OnChange(changed)
+}
+
+// indexDir queues every Go file in a newly created directory.
+func (w *Watcher) indexDir(dir string, pending map[string]fsnotify.Op) {
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(path, ".go") {
+			pending[path] |= fsnotify.Create
+		}
+		return nil
+	})
+}
+
+func (w *Watcher) report(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+	}
+}
+
+// addRecursive watches dir and all of its subdirectories except hidden, vendor and node_modules.
+func addRecursive(fw *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		name := d.Name()
+		if path != dir && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules") {
+			return filepath.SkipDir
+		}
+		return fw.Add(path)
+	})
+}
+
+// File calls onChange whenever the file at path is written or replaced, until ctx is cancelled.
+// The parent directory is watched so editors that save by renaming are picked up too.
+func File(ctx context.Context, path string, onChange func()) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+	if err := fw.Add(filepath.Dir(path)); err != nil {
+		return err
+	}
+	target := filepath.Clean(path)
+	timer := time.NewTimer(0)
+	<-timer.C
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-fw.Errors:
+			if !ok {
+				return nil
+			}
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(ev.Name) == target && ev.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+				timer.Reset(100 * time.Millisecond)
+			}
+		case <-timer.C:
+			onChange()
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package socketeer_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/muratmirgun/socketeer/pkg/socketeer"
+)
+
+func ExampleGenerate() {
+	s, diags, err := socketeer.Generate(socketeer.GenerateOptions{Dirs: []string{"gen/testdata/chat"}, Sort: true})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, d := range diags {
+		fmt.Println(d)
+	}
+	for _, sock := range s.Sockets {
+		for _, m := range sock.Messages {
+			fmt.Println(sock.Name, m.Direction, m.Type, m.Payload)
+		}
+	}
+	// Output:
+	// Room receive join {"ok":true}
+	// Room send join {"room":"string"}
+}
@@ -0,0 +1,32 @@
+package gen_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/muratmirgun/socketeer/pkg/socketeer/gen"
+)
+
+func ExampleParse() {
+	s, diags, err := gen.Parse(gen.Options{Dirs: []string{"testdata/chat"}})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, d := range diags {
+		fmt.Println(d)
+	}
+	for _, sock := range s.Sockets {
+		fmt.Println(sock.Name, sock.URL)
+		for _, p := range sock.ConnectionParams {
+			fmt.Printf("  %s in %s: %s\n", p.Name, p.In, p.Description)
+		}
+		for _, m := range sock.Messages {
+			fmt.Println(" ", m.Direction, m.Type, m.Payload)
+		}
+	}
+	// Output:
+	// Room /ws/chat
+	//   token in query: Access token
+	//   send join {"room":"string"}
+	//   receive join {"ok":true}
+}
@@ -0,0 +1,71 @@
+// Package gen generates Socketeer specs from annotated Go sources, like
+// socketeer generate but without writing files or reading .socketeer.yaml.
+// It follows the compatibility rules of package spec.
+//
+// Example:
+//
+//	s, diags, err := gen.Parse(gen.Options{Dirs: []string{"./internal"}})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, d := range diags {
+//		log.Println(d)
+//	}
+//	if err := spec.Save(s, "wsdocs/wsapi.json"); err != nil {
+//		log.Fatal(err)
+//	}
+package gen
+
+import (
+	"github.com/muratmirgun/socketeer/internal/parser"
+	"github.com/muratmirgun/socketeer/pkg/socketeer/spec"
+)
+
+// Options selects the Go sources Parse scans, like the source section of
+// .socketeer.yaml. The zero value scans the working directory.
+type Options struct {
+	// Dirs are the source directories (default: the working directory)
+	Dirs []string
+	// Include and Exclude are globs relative to a source directory
+	Include []string
+	Exclude []string
+	// Tags are build tags files are matched against, as with go build -tags
+	Tags []string
+	// Generated includes files marked "Code generated ... DO NOT EDIT."
+	Generated bool
+	// Infer adds undocumented messages found in handler code
+	Infer bool
+	// Sort orders sockets, messages and payload keys alphabetically
+	Sort bool
+	// CacheDir keeps parse results between calls, empty to disable
+	CacheDir string
+	// Info and Servers are used where the sources declare no API info or servers
+	Info    spec.Info
+	Servers []spec.Server
+}
+
+// Diagnostic is a problem found in the sources that does not stop the spec
+// from being generated, e.g. a file that could not be parsed. Its String
+// method formats it like socketeer generate prints it.
+type Diagnostic = parser.Diagnostic
+
+// Parse scans the sources and returns their spec along with the problems found.
+func Parse(opts Options) (*spec.Spec, []Diagnostic, error) {
+	dirs := opts.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{"./"}
+	}
+	ix := parser.NewIndex(dirs...)
+	ix.Include, ix.Exclude = opts.Include, opts.Exclude
+	ix.Tags, ix.Generated = opts.Tags, opts.Generated
+	ix.Infer, ix.CacheDir = opts.Infer, opts.CacheDir
+	ix.Defaults, ix.DefaultServers = opts.Info, opts.Servers
+	if err := ix.Load(); err != nil {
+		return nil, nil, err
+	}
+	s, diags := ix.Build()
+	if opts.Sort {
+		s.Sort()
+	}
+	return &s, diags, nil
+}
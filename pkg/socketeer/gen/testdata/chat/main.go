@@ -0,0 +1,17 @@
+package chat
+
+// Join asks to enter a room.
+type Join struct {
+	Room string `json:"room"`
+}
+
+// @WebSocket Room
+// @URL /ws/chat
+// @Description Chat rooms
+// @ConnectionParam token in=query type=string required=true Access token
+// @Message join
+// @Send
+// @Payload Join
+// @Receive
+// @Payload {"ok": true}
+func ServeChat() {}
@@ -0,0 +1,27 @@
+package socketeer
+
+import (
+	"github.com/muratmirgun/socketeer/pkg/socketeer/gen"
+	"github.com/muratmirgun/socketeer/pkg/socketeer/spec"
+)
+
+// Spec is a parsed spec, as returned by Generate. It is spec.Spec.
+type Spec = spec.Spec
+
+// Diagnostic is a problem found while generating a spec, e.g. a file that
+// could not be parsed. It is gen.Diagnostic.
+type Diagnostic = gen.Diagnostic
+
+// GenerateOptions selects the Go sources Generate scans. It is gen.Options.
+type GenerateOptions = gen.Options
+
+// Generate parses Go sources and returns their spec without writing any file,
+// along with the problems found. It is gen.Parse.
+func Generate(opts GenerateOptions) (*Spec, []Diagnostic, error) {
+	return gen.Parse(opts)
+}
+
+// EncodeSpec encodes a spec as "yaml" or "json", as written by socketeer generate.
+func EncodeSpec(s *Spec, format string) ([]byte, error) {
+	return spec.Encode(s, format)
+}
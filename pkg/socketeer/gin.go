@@ -1,6 +1,7 @@
 package socketeer
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/muratmirgun/socketeer/pkg/socketeer/spec"
 )
 
 // GinMiddleware returns a Gin middleware that serves Socketeer documentation
@@ -33,11 +35,11 @@ func GinMiddleware(config *Config) gin.HandlerFunc {
 			serveDocsPageGin(c, config)
 			return
 		}
-		if c.Request.URL.Path == docsPath+"/wsapi.yaml" {
-			serveSpecFileGin(c, config.SpecPath)
-			return
-		}
-		if c.Request.URL.Path == "/wsapi.yaml" {
+		if c.Request.URL.Path == docsPath+"/wsapi.yaml" || c.Request.URL.Path == "/wsapi.yaml" {
+			if len(config.Services) > 0 {
+				serveMergedSpecGin(c, config)
+				return
+			}
 			serveSpecFileGin(c, config.SpecPath)
 			return
 		}
@@ -82,6 +84,25 @@ func serveSpecFileGin(c *gin.Context, specPath string) {
 	c.Data(http.StatusOK, "application/x-yaml", content)
 }
 
+func serveMergedSpecGin(c *gin.Context, config *Config) {
+	content, err := config.MergedSpec()
+	var conflicts *spec.ConflictError
+	if errors.As(err, &conflicts) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "The service specifications conflict",
+			"conflicts": conflicts.Conflicts,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to merge specifications: %v", err),
+		})
+		return
+	}
+	c.Data(http.StatusOK, "application/x-yaml", content)
+}
+
 func serveStaticFileGin(c *gin.Context, staticPath, filename string) {
 	filePath := filepath.Join(staticPath, filename)
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -0,0 +1,67 @@
+package socketeer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/muratmirgun/socketeer/pkg/socketeer/spec"
+)
+
+// ErrMessageNotAllowed is returned when a message is not allowed in the current protocol state.
+var ErrMessageNotAllowed = errors.New("message not allowed in current state")
+
+// Session enforces a socket's documented protocol state machine for a single connection.
+// Handlers opt in by creating one Session per connection and calling Handle for every message.
+type Session struct {
+	mu       sync.Mutex
+	protocol *spec.Protocol
+	state    string
+}
+
+// NewSession returns a Session starting in the socket's initial state.
+// Sockets without a protocol section accept every message.
+func NewSession(socket *spec.Socket) *Session {
+	s := &Session{protocol: socket.Protocol}
+	if s.protocol != nil {
+		s.state = s.protocol.Initial
+	}
+	return s
+}
+
+// LoadSocket reads the spec at specPath and returns the socket with the given name.
+func LoadSocket(specPath, name string) (*spec.Socket, error) {
+	s, err := spec.Load(specPath)
+	if err != nil {
+		return nil, err
+	}
+	for i := range s.Sockets {
+		if s.Sockets[i].Name == name {
+			return &s.Sockets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("socket %q not found in %s", name, specPath)
+}
+
+// State returns the current protocol state.
+func (s *Session) State() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Handle records that a message of the given type was exchanged and advances the state.
+// It returns an error wrapping ErrMessageNotAllowed if the message is not allowed in the current state.
+func (s *Session) Handle(messageType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.protocol == nil || !s.protocol.Constrains(messageType) {
+		return nil
+	}
+	next, ok := s.protocol.Next(s.state, messageType)
+	if !ok {
+		return fmt.Errorf("%w: %q in state %q", ErrMessageNotAllowed, messageType, s.state)
+	}
+	s.state = next
+	return nil
+}
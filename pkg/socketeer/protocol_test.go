@@ -0,0 +1,65 @@
+package socketeer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSessionHandle(t *testing.T) {
+	room, err := LoadSocket("testdata/protocol.yaml", "Room")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSession(room)
+	if got := s.State(); got != "connected" {
+		t.Fatalf("initial state = %q, want connected", got)
+	}
+
+	steps := []struct {
+		message string
+		allowed bool
+		state   string // after the message
+	}{
+		{"join", false, "connected"}, // before auth
+		{"auth", true, "authenticated"},
+		{"typing", true, "authenticated"}, // not part of the state machine
+		{"join", true, "in_room"},
+		{"auth", false, "in_room"},
+		{"leave", true, "authenticated"},
+	}
+	for _, step := range steps {
+		err := s.Handle(step.message)
+		if step.allowed && err != nil {
+			t.Errorf("Handle(%q) = %v, want it allowed", step.message, err)
+		}
+		if !step.allowed && !errors.Is(err, ErrMessageNotAllowed) {
+			t.Errorf("Handle(%q) = %v, want ErrMessageNotAllowed", step.message, err)
+		}
+		if got := s.State(); got != step.state {
+			t.Errorf("state after %q = %q, want %q", step.message, got, step.state)
+		}
+	}
+}
+
+func TestSessionWithoutProtocol(t *testing.T) {
+	feed, err := LoadSocket("testdata/protocol.yaml", "Feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSession(feed)
+	for _, m := range []string{"subscribe", "anything"} {
+		if err := s.Handle(m); err != nil {
+			t.Errorf("Handle(%q) = %v, want every message allowed", m, err)
+		}
+	}
+	if got := s.State(); got != "" {
+		t.Errorf("state = %q, want none", got)
+	}
+}
+
+func TestLoadSocketNotFound(t *testing.T) {
+	_, err := LoadSocket("testdata/protocol.yaml", "Lobby")
+	if want := `socket "Lobby" not found in testdata/protocol.yaml`; err == nil || err.Error() != want {
+		t.Errorf("LoadSocket error = %v, want %s", err, want)
+	}
+}
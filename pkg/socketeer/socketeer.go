@@ -1,5 +1,7 @@
 package socketeer
 
+import "github.com/muratmirgun/socketeer/pkg/socketeer/spec"
+
 // SocketeerConfig holds configuration for the Socketeer middleware
 // This is shared by all framework integrations (Gin, Fiber, Echo, ...)
 type Config struct {
@@ -13,6 +15,40 @@ type Config struct {
 	Title string
 	// Whether to enable CORS for the documentation
 	EnableCORS bool
+	// Specs of several services, merged and served instead of SpecPath so
+	// that one portal documents all of them
+	Services []Service
+	// How Services are merged; service names are taken from Services
+	Merge spec.MergeOptions
+}
+
+// Service is the spec of one service in an aggregated portal.
+type Service struct {
+	// Name shown as the owner of the service's sockets (default: the spec's title)
+	Name string
+	// Path to the service's wsapi.yaml or JSON spec
+	SpecPath string
+}
+
+// MergedSpec loads and merges the specs of c.Services and encodes the
+// result as YAML. It returns a *spec.ConflictError if they conflict.
+func (c *Config) MergedSpec() ([]byte, error) {
+	opts := c.Merge
+	opts.Services = nil
+	var specs []*spec.Spec
+	for _, svc := range c.Services {
+		s, err := spec.Load(svc.SpecPath)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, s)
+		opts.Services = append(opts.Services, svc.Name)
+	}
+	merged, err := spec.MergeWith(opts, specs...)
+	if err != nil {
+		return nil, err
+	}
+	return spec.Encode(merged, spec.FormatYAML)
 }
 
 // DefaultConfig returns default configuration
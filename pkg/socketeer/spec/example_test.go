@@ -0,0 +1,69 @@
+package spec_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/muratmirgun/socketeer/pkg/socketeer/spec"
+)
+
+func ExampleLoad() {
+	s, err := spec.Load("testdata/chat.yaml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(s.Info.Title, s.Info.Version)
+	for _, sock := range s.Sockets {
+		fmt.Println(sock.Name, sock.URL)
+		for _, m := range sock.Messages {
+			fmt.Println(" ", m.Direction, m.Type)
+		}
+	}
+	// Output:
+	// Chat 1.0.0
+	// Room /ws/chat
+	//   send join
+	//   receive message
+}
+
+func ExampleMerge() {
+	chat, err := spec.Load("testdata/chat.yaml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	presence, err := spec.Load("testdata/presence.yaml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	merged, err := spec.Merge(chat, presence)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(merged.Info.Title)
+	for _, sock := range merged.Sockets {
+		fmt.Println(sock.Service, sock.Name, sock.URL)
+	}
+	// Output:
+	// Chat
+	// Chat Room /ws/chat
+	// Presence Status /ws/presence
+}
+
+func ExampleDiff() {
+	before, err := spec.Load("testdata/chat.yaml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	after, err := spec.Load("testdata/chat.v2.yaml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, c := range spec.Diff(before, after) {
+		fmt.Println(c)
+	}
+	// Output:
+	// info.version: version "1.0.0" is now "2.0.0"
+	// sockets[Room].connectionParams[token]: query parameter removed
+	// sockets[Room].messages[receive message]: message removed (breaking)
+	// sockets[Room].messages[send typing]: message added
+}
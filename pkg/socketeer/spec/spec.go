@@ -0,0 +1,142 @@
+// Package spec is the public API for reading, writing and comparing Socketeer
+// specs (wsapi.yaml). The types are the ones the socketeer command uses, so a
+// spec loaded here and saved again is what socketeer generate would write.
+//
+// # Compatibility
+//
+// The packages under pkg/socketeer follow semantic versioning: within a major
+// version, exported names are not removed or changed incompatibly. New fields
+// and spec keys may be added in minor versions, so construct the types with
+// field names rather than positionally. Unknown keys in a spec file are
+// ignored when loading. The messages returned by Validate and the paths and
+// messages of a Change are meant for people and may be reworded; compare
+// Change.Kind and Change.Breaking instead.
+//
+// # Example
+//
+// Failing a build when a change breaks clients:
+//
+//	before, err := spec.Load("wsapi.main.yaml")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	after, err := spec.Load("wsdocs/wsapi.yaml")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if problems := spec.Validate(after); len(problems) > 0 {
+//		log.Fatal(strings.Join(problems, "\n"))
+//	}
+//	for _, c := range spec.Diff(before, after) {
+//		if c.Breaking {
+//			log.Fatal(c)
+//		}
+//	}
+package spec
+
+import (
+	ispec "github.com/muratmirgun/socketeer/internal/spec"
+)
+
+// The spec types, see the README for the meaning of each key.
+type (
+	Spec              = ispec.Spec
+	Info              = ispec.Info
+	Contact           = ispec.Contact
+	License           = ispec.License
+	Server            = ispec.Server
+	ServerVariable    = ispec.ServerVariable
+	Socket            = ispec.Socket
+	ConnectionParam   = ispec.ConnectionParam
+	Message           = ispec.Message
+	GroupedMessage    = ispec.GroupedMessage
+	Error             = ispec.Error
+	Example           = ispec.Example
+	Schema            = ispec.Schema
+	Properties        = ispec.Properties
+	Property          = ispec.Property
+	EnumValue         = ispec.EnumValue
+	Field             = ispec.Field
+	HandshakeResponse = ispec.HandshakeResponse
+	Heartbeat         = ispec.Heartbeat
+	RateLimit         = ispec.RateLimit
+	CloseCode         = ispec.CloseCode
+	Protocol          = ispec.Protocol
+	State             = ispec.State
+	Transition        = ispec.Transition
+)
+
+// File formats accepted by Encode.
+const (
+	FormatYAML = ispec.FormatYAML
+	FormatJSON = ispec.FormatJSON
+)
+
+// Load reads a spec file, YAML or JSON.
+func Load(path string) (*Spec, error) {
+	return ispec.Load(path)
+}
+
+// Decode decodes a spec from YAML or JSON.
+func Decode(data []byte) (*Spec, error) {
+	return ispec.Decode(data)
+}
+
+// Save writes a spec to path, as JSON if it ends in .json and as YAML otherwise.
+func Save(s *Spec, path string) error {
+	return ispec.Save(s, path)
+}
+
+// Encode encodes a spec as FormatYAML or FormatJSON, formatted like socketeer generate.
+func Encode(s *Spec, format string) ([]byte, error) {
+	return ispec.Encode(s, format)
+}
+
+// Validate checks a spec like socketeer validate and returns one message per
+// problem, or none if the spec is valid.
+func Validate(s *Spec) []string {
+	return s.Validate()
+}
+
+// MergeOptions configures MergeWith: service names, namespacing and the info policy.
+type MergeOptions = ispec.MergeOptions
+
+// ConflictError is returned by Merge and MergeWith with every conflict found.
+type ConflictError = ispec.ConflictError
+
+// Info policies of MergeOptions.
+const (
+	InfoFirst  = ispec.InfoFirst
+	InfoLast   = ispec.InfoLast
+	InfoStrict = ispec.InfoStrict
+)
+
+// Merge combines the specs of several services into one with the default
+// MergeOptions. Info comes from the first spec, with empty fields filled from
+// the following ones. Servers with the same name must be identical, and
+// socket names and URLs must be unique.
+func Merge(specs ...*Spec) (*Spec, error) {
+	return ispec.Merge(specs...)
+}
+
+// MergeWith combines the specs of several services into one. Each socket
+// records the service it comes from in Socket.Service.
+func MergeWith(opts MergeOptions, specs ...*Spec) (*Spec, error) {
+	return ispec.MergeWith(opts, specs...)
+}
+
+// Change is a difference found by Diff.
+type Change = ispec.Change
+
+// Kinds of Change.
+const (
+	Added   = ispec.Added
+	Removed = ispec.Removed
+	Changed = ispec.Changed
+)
+
+// Diff returns the changes from one version of a spec to the next, marking
+// those that can break existing clients.
+func Diff(from, to *Spec) []Change {
+	return ispec.Diff(from, to)
+}